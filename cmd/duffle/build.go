@@ -26,7 +26,9 @@ import (
 	"github.com/cnabio/duffle/pkg/imagebuilder"
 	"github.com/cnabio/duffle/pkg/imagebuilder/docker"
 	"github.com/cnabio/duffle/pkg/imagebuilder/mock"
+	"github.com/cnabio/duffle/pkg/imagediscovery"
 	"github.com/cnabio/duffle/pkg/ohai"
+	"github.com/cnabio/duffle/pkg/osutil"
 	"github.com/cnabio/duffle/pkg/repo"
 )
 
@@ -34,6 +36,9 @@ const buildDesc = `
 Builds a Cloud Native Application Bundle (CNAB) given a path to a directory that has a duffle configuration file (duffle.json).
 
 It builds the invocation images specified in the duffle configuration file and then creates or updates the bundle in local storage with the latest invocation images.
+
+If --discover-images is set, the Kubernetes manifests and Helm charts under cnab/app are scanned for 'image:' references,
+which are added to (or updated in) the bundle's images. The duffle configuration file itself is not modified.
 `
 
 const (
@@ -51,6 +56,8 @@ type buildCmd struct {
 	home       home.Home
 	outputFile string
 
+	discoverImages bool
+
 	// options common to the docker client and the daemon.
 	dockerClientOptions *dockerflags.ClientOptions
 }
@@ -86,6 +93,7 @@ func newBuildCmd(out io.Writer) *cobra.Command {
 
 	f = cmd.Flags()
 	f.StringVarP(&build.outputFile, "output-file", "o", "", "If set, writes the bundle to this file in addition to saving it to the local store")
+	f.BoolVar(&build.discoverImages, "discover-images", false, "Add the images referenced by Kubernetes manifests and Helm charts in cnab/app to the bundle")
 
 	f.BoolVar(&build.dockerClientOptions.Common.Debug, "docker-debug", false, "Enable debug mode")
 	f.StringVar(&build.dockerClientOptions.Common.LogLevel, "docker-log-level", "info", `Set the logging level ("debug"|"info"|"warn"|"error"|"fatal")`)
@@ -120,6 +128,12 @@ func (b *buildCmd) run() (err error) {
 		return err
 	}
 
+	if b.discoverImages {
+		if err := b.addDiscoveredImages(mfst); err != nil {
+			return err
+		}
+	}
+
 	imagebuilders, err := b.prepareImageBuilders(mfst)
 	if err != nil {
 		return fmt.Errorf("cannot configure necessary image builders: %v", err)
@@ -134,33 +148,49 @@ func (b *buildCmd) run() (err error) {
 		return err
 	}
 
-	digest, err := b.writeBundle(bf)
-	if err != nil {
+	if err := b.writeBundle(bf); err != nil {
 		return err
 	}
+	ohai.Fsuccessf(b.out, "Successfully built bundle %s:%s\n", bf.Name, bf.Version)
 
-	// record the new bundle in repositories.json
-	if err := recordBundleReference(b.home, bf.Name, bf.Version, digest); err != nil {
-		return fmt.Errorf("could not record bundle: %v", err)
+	return nil
+}
+
+// addDiscoveredImages adds the images referenced by the app directory of the bundle to the manifest.
+func (b *buildCmd) addDiscoveredImages(mfst *manifest.Manifest) error {
+	appDir := filepath.Join(b.src, "cnab", "app")
+	refs, err := imagediscovery.Discover(appDir)
+	if err != nil {
+		return fmt.Errorf("cannot discover images in %s: %v", appDir, err)
 	}
-	ohai.Fsuccessf(b.out, "Successfully built bundle %s:%s\n", bf.Name, bf.Version)
 
+	changed, err := mfst.AddImages(refs)
+	if err != nil {
+		return fmt.Errorf("cannot add discovered images: %v", err)
+	}
+	for _, name := range changed {
+		fmt.Fprintf(b.out, "Discovered image %s: %s\n", name, mfst.Images[name].Image)
+	}
 	return nil
 }
 
-func (b *buildCmd) writeBundle(bf *bundle.Bundle) (string, error) {
+func (b *buildCmd) writeBundle(bf *bundle.Bundle) error {
 	data, digest, err := marshalBundle(bf)
 	if err != nil {
-		return "", fmt.Errorf("cannot marshal bundle: %v", err)
+		return fmt.Errorf("cannot marshal bundle: %v", err)
 	}
 
 	if b.outputFile != "" {
 		if err := ioutil.WriteFile(b.outputFile, data, 0644); err != nil {
-			return "", fmt.Errorf("cannot write bundle to %s: %v", b.outputFile, err)
+			return fmt.Errorf("cannot write bundle to %s: %v", b.outputFile, err)
 		}
 	}
 
-	return digest, ioutil.WriteFile(filepath.Join(b.home.Bundles(), digest), data, 0644)
+	// store the new bundle and record it in repositories.json
+	if err := addBundle(b.home, bf.Name, bf.Version, digest, data); err != nil {
+		return fmt.Errorf("could not record bundle: %v", err)
+	}
+	return nil
 }
 
 func marshalBundle(bf *bundle.Bundle) ([]byte, string, error) {
@@ -170,12 +200,19 @@ func marshalBundle(bf *bundle.Bundle) ([]byte, string, error) {
 	}
 	data = append(data, '\n') //TODO: why?
 
-	digest, err := digest.OfBuffer(data)
-	if err != nil {
-		return nil, "", fmt.Errorf("cannot compute digest from bundle: %v", err)
-	}
+	return data, digest.OfBuffer(data).String(), nil
+}
 
-	return data, digest, nil
+// storeBundle writes the marshaled bundle to the local store under its digest.
+//
+// Unless the bundle is already referenced, the index lock must be held until it is recorded in the index, or
+// 'duffle bundle prune' may delete it in between.
+func storeBundle(h home.Home, digest string, data []byte) error {
+	dest := h.BundleFile(digest)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(dest, data, 0644)
 }
 
 func defaultDockerTLS() bool {
@@ -218,17 +255,31 @@ func (b *buildCmd) prepareImageBuilders(mfst *manifest.Manifest) ([]imagebuilder
 	return imagebuilders, nil
 }
 
-func recordBundleReference(home home.Home, name, version, digest string) error {
-	// record the new bundle in repositories.json
-	index, err := repo.LoadIndex(home.Repositories())
+// addBundle stores the marshaled bundle and records it in the index as the given name and version, under a single hold
+// of the index lock.
+func addBundle(home home.Home, name, version, digest string, data []byte) error {
+	err := repo.UpdateIndex(home.Repositories(), 0644, func(index repo.Index) error {
+		if err := storeBundle(home, digest, data); err != nil {
+			return err
+		}
+		index.Add(name, version, digest)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("cannot create or open %s: %v", home.Repositories(), err)
+		return fmt.Errorf("could not record bundle in %s: %v", home.Repositories(), err)
 	}
 
-	index.Add(name, version, digest)
+	return nil
+}
 
-	if err := index.WriteFile(home.Repositories(), 0644); err != nil {
-		return fmt.Errorf("could not write to %s: %v", home.Repositories(), err)
+func recordBundleReference(home home.Home, name, version, digest string) error {
+	// record the new bundle in repositories.json
+	err := repo.UpdateIndex(home.Repositories(), 0644, func(index repo.Index) error {
+		index.Add(name, version, digest)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not record bundle in %s: %v", home.Repositories(), err)
 	}
 
 	return nil
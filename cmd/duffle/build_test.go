@@ -2,10 +2,12 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -69,8 +71,109 @@ func TestBuild(t *testing.T) {
 		t.Fatalf("could not find bundle: %v", err)
 	}
 
-	loc := filepath.Join(testHome.Bundles(), digest)
+	loc := testHome.BundleFile(digest)
 	is.FileExists(loc)
 	_, err = ioutil.ReadFile(loc)
 	is.NoError(err)
 }
+
+func TestBuildDiscoverImages(t *testing.T) {
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	testBundlePath, err := ioutil.TempDir("", "dufflebundles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testBundlePath)
+
+	manifest, err := ioutil.ReadFile(filepath.Join("testdata", "testbundle", "duffle.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(testBundlePath, "duffle.json"), manifest, 0644); err != nil {
+		t.Fatal(err)
+	}
+	appDir := filepath.Join(testBundlePath, "cnab", "app")
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	deployment := []byte("kind: Pod\nspec:\n  containers:\n  - name: web\n    image: nginx:1.17\n")
+	if err := ioutil.WriteFile(filepath.Join(appDir, "pod.yaml"), deployment, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	cmd := &buildCmd{
+		home:           testHome,
+		src:            testBundlePath,
+		out:            out,
+		discoverImages: true,
+	}
+	if err := cmd.run(); err != nil {
+		t.Fatalf("Expected no error but got err: %s", err)
+	}
+
+	is := assert.New(t)
+	is.Contains(out.String(), "Discovered image nginx: nginx:1.17")
+
+	index, err := repo.LoadIndex(testHome.Repositories())
+	is.NoError(err)
+	digest, err := index.Get("testbundle", "")
+	is.NoError(err)
+	bun, err := loadBundle(testHome.BundleFile(digest))
+	is.NoError(err)
+	is.Equal("nginx:1.17", bun.Images["nginx"].Image)
+}
+
+func TestBuildConcurrent(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	tempBundleDir, err := ioutil.TempDir("", "dufflebundles")
+	is.NoError(err)
+	defer os.RemoveAll(tempBundleDir)
+
+	const builds = 10
+	srcs := make([]string, builds)
+	for n := range srcs {
+		srcs[n] = filepath.Join(tempBundleDir, fmt.Sprintf("testbundle-%d", n))
+		is.NoError(os.MkdirAll(filepath.Join(srcs[n], "cnab"), 0755))
+		manifest := fmt.Sprintf(`{
+    "name": "testbundle",
+    "version": "1.0.%d",
+    "invocationImages": {
+        "cnab": {
+            "name": "cnab",
+            "builder": "mock"
+        }
+    }
+}`, n)
+		is.NoError(ioutil.WriteFile(filepath.Join(srcs[n], "duffle.json"), []byte(manifest), 0644))
+	}
+
+	var wg sync.WaitGroup
+	for _, src := range srcs {
+		wg.Add(1)
+		go func(src string) {
+			defer wg.Done()
+			cmd := &buildCmd{
+				home: testHome,
+				src:  src,
+				out:  ioutil.Discard,
+			}
+			is.NoError(cmd.run())
+		}(src)
+	}
+	wg.Wait()
+
+	index, err := repo.LoadIndex(testHome.Repositories())
+	is.NoError(err)
+	vers, ok := index.GetVersions("testbundle")
+	is.True(ok)
+	is.Len(vers, builds, "no index entries should be lost")
+	for _, ver := range vers {
+		is.FileExists(testHome.BundleFile(ver.Digest))
+	}
+}
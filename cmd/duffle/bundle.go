@@ -22,7 +22,10 @@ func newBundleCmd(w io.Writer) *cobra.Command {
 		newInstallCmd(w),
 		newBundleShowCmd(w),
 		newBundleRemoveCmd(w),
+		newBundlePruneCmd(w),
+		newBundleTagCmd(w),
 		newBundleActionsCmd(w),
+		newBundleDepsCmd(w),
 	)
 	return cmd
 }
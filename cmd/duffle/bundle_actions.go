@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/cnabio/duffle/pkg/duffle/home"
 
@@ -17,6 +18,15 @@ type bundleActionsCmd struct {
 	home         home.Home
 	bundle       string
 	bundleIsFile bool
+	output       outputFormat
+}
+
+// actionItem is a custom action as printed by duffle bundle actions.
+type actionItem struct {
+	Name        string `json:"name"`
+	Stateless   bool   `json:"stateless"`
+	Modifies    bool   `json:"modifies"`
+	Description string `json:"description"`
 }
 
 func newBundleActionsCmd(w io.Writer) *cobra.Command {
@@ -26,6 +36,7 @@ func newBundleActionsCmd(w io.Writer) *cobra.Command {
 		Use:   "actions BUNDLE",
 		Short: bundleActionsDesc,
 		Long:  bundleActionsDesc,
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			a.bundle = args[0]
 			a.home = home.Home(homePath())
@@ -35,11 +46,15 @@ func newBundleActionsCmd(w io.Writer) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&a.bundleIsFile, "bundle-is-file", "f", false, "Indicates that the bundle source is a file path")
+	a.output.addFlag(cmd.Flags())
 
 	return cmd
 }
 
 func (a *bundleActionsCmd) run() error {
+	if err := a.output.validate(); err != nil {
+		return err
+	}
 	bundleFile, err := resolveBundleFilePath(a.bundle, a.home.String(), a.bundleIsFile)
 	if err != nil {
 		return err
@@ -50,16 +65,23 @@ func (a *bundleActionsCmd) run() error {
 		return err
 	}
 
-	table := uitable.New()
-	table.MaxColWidth = 100
-	table.Wrap = true
-
-	table.AddRow("ACTION", "STATELESS", "MODIFIES", "DESCRIPTION")
+	items := make([]actionItem, 0, len(bun.Actions))
 	for name, act := range bun.Actions {
-		table.AddRow(name, act.Stateless, act.Modifies, act.Description)
+		items = append(items, actionItem{Name: name, Stateless: act.Stateless, Modifies: act.Modifies, Description: act.Description})
 	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	return a.output.print(a.out, items, func() error {
+		table := uitable.New()
+		table.MaxColWidth = 100
+		table.Wrap = true
 
-	fmt.Fprintln(a.out, table)
+		table.AddRow("ACTION", "STATELESS", "MODIFIES", "DESCRIPTION")
+		for _, item := range items {
+			table.AddRow(item.Name, item.Stateless, item.Modifies, item.Description)
+		}
 
-	return nil
+		fmt.Fprintln(a.out, table)
+		return nil
+	})
 }
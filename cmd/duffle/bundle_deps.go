@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/dependencies"
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+const bundleDepsDesc = `
+Print the tree of the dependencies of a bundle.
+
+Dependencies are declared with the io.cnab.dependencies extension. Each dependency is resolved
+to the highest version of its bundle in the local bundle store that satisfies its version
+ranges, as 'duffle install' does. Dependencies that cannot be resolved are marked, and the
+command fails.
+`
+
+type bundleDepsCmd struct {
+	out          io.Writer
+	home         home.Home
+	bundle       string
+	bundleIsFile bool
+}
+
+func newBundleDepsCmd(w io.Writer) *cobra.Command {
+	deps := &bundleDepsCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:     "deps BUNDLE",
+		Aliases: []string{"dependencies"},
+		Short:   "print the dependency tree of a bundle",
+		Long:    bundleDepsDesc,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			deps.bundle = args[0]
+			deps.home = home.Home(homePath())
+			return deps.run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&deps.bundleIsFile, "bundle-is-file", "f", false, "Indicates that the bundle source is a file path")
+
+	return cmd
+}
+
+func (d *bundleDepsCmd) run() error {
+	bundleFile, err := resolveBundleFilePath(d.bundle, d.home.String(), d.bundleIsFile)
+	if err != nil {
+		return err
+	}
+	bun, err := loadBundle(bundleFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(d.out, "%s:%s\n", bun.Name, bun.Version)
+	if _, ok := bun.Custom[dependencies.ExtensionKey]; !ok {
+		fmt.Fprintln(d.out, "  (no dependencies)")
+		return nil
+	}
+
+	index, err := repo.LoadIndex(d.home.Repositories())
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %v", d.home.Repositories(), err)
+	}
+	nodes, err := dependencies.Resolve(bun, index, func(digest string) (*bundle.Bundle, error) {
+		return loadBundle(d.home.BundleFile(digest))
+	})
+	if err != nil {
+		return err
+	}
+	printDependencyTree(d.out, nodes, 1)
+	if err := dependencies.Check(nodes); err != nil {
+		return fmt.Errorf("some dependencies of %s cannot be resolved", bun.Name)
+	}
+	return nil
+}
+
+// printDependencyTree prints the dependencies, indenting each level of the tree.
+func printDependencyTree(w io.Writer, nodes []*dependencies.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, n := range nodes {
+		if n.Err != nil {
+			fmt.Fprintf(w, "%s%s: cannot be resolved: %v\n", indent, n.Alias, n.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s: %s:%s", indent, n.Alias, n.Bundle.Name, n.Bundle.Version)
+		if c := n.Dependency.Constraint(); c != "" {
+			fmt.Fprintf(w, " (%s)", c)
+		}
+		fmt.Fprintln(w)
+		printDependencyTree(w, n.Dependencies, depth+1)
+	}
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
-	"path/filepath"
+	"os"
 	"sort"
 	"strings"
 
@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/ohai"
 	"github.com/cnabio/duffle/pkg/repo"
 )
 
@@ -56,15 +57,33 @@ func (n *NamedRepository) Digest() string {
 	return n.digest
 }
 
+// bundleItem is a bundle as printed by duffle bundle list.
+type bundleItem struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Digest  string `json:"digest"`
+}
+
 func newBundleListCmd(w io.Writer) *cobra.Command {
-	var short bool
+	var (
+		short  bool
+		output outputFormat
+	)
 	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "list bundles pulled or built and stored locally",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.validateShort(short); err != nil {
+				return err
+			}
 			home := home.Home(homePath())
-			references, err := searchLocal(home)
+			// keep warnings out of documents meant for other programs
+			warnings := w
+			if !output.table() {
+				warnings = os.Stderr
+			}
+			references, err := searchLocal(home, warnings)
 			if err != nil {
 				return err
 			}
@@ -76,22 +95,31 @@ func newBundleListCmd(w io.Writer) *cobra.Command {
 				return nil
 			}
 
-			table := uitable.New()
-			table.AddRow("NAME", "VERSION", "DIGEST")
+			items := make([]bundleItem, 0, len(references))
 			for _, ref := range references {
-				table.AddRow(ref.Name(), ref.Tag(), ref.Digest())
+				items = append(items, bundleItem{Name: ref.Name(), Version: ref.Tag(), Digest: ref.Digest()})
 			}
-			fmt.Fprintln(w, table)
-
-			return nil
+			return output.print(w, items, func() error {
+				table := uitable.New()
+				table.AddRow("NAME", "VERSION", "DIGEST")
+				for _, item := range items {
+					table.AddRow(item.Name, item.Version, item.Digest)
+				}
+				fmt.Fprintln(w, table)
+				return nil
+			})
 		},
 	}
 	cmd.Flags().BoolVarP(&short, "short", "s", false, "output shorter listing format")
+	output.addFlag(cmd.Flags())
 
 	return cmd
 }
 
-func searchLocal(home home.Home) (NamedRepositoryList, error) {
+// searchLocal returns the bundles in the local index.
+//
+// Index entries whose bundle cannot be loaded are skipped with a warning written to w.
+func searchLocal(home home.Home, w io.Writer) (NamedRepositoryList, error) {
 	references := NamedRepositoryList{}
 
 	index, err := repo.LoadIndex(home.Repositories())
@@ -101,9 +129,10 @@ func searchLocal(home home.Home) (NamedRepositoryList, error) {
 
 	for repo, tagList := range index {
 		for tag, digest := range tagList {
-			_, err := loadBundle(filepath.Join(home.Bundles(), digest))
+			_, err := loadBundle(home.BundleFile(digest))
 			if err != nil {
-				return nil, err
+				ohai.Fwarningf(w, "skipping %s:%s: %v (run 'duffle doctor' to check the local storage)\n", repo, tag, err)
+				continue
 			}
 			references = append(references, &NamedRepository{
 				repo,
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+const bundlePruneDesc = `Delete bundle files that are no longer referenced from the local storage.
+
+'duffle bundle remove' only deletes bundles from the index when they are removed by name,
+so files left behind by interrupted builds or older versions of Duffle can accumulate.
+This deletes every file in the local bundle storage that no bundle name or version refers to.
+
+If '--keep-claimed' is set, bundles that existing installations were performed with are
+kept as well, even when they are no longer in the index.
+`
+
+type bundlePruneCmd struct {
+	home        home.Home
+	out         io.Writer
+	keepClaimed bool
+	dryRun      bool
+}
+
+func newBundlePruneCmd(w io.Writer) *cobra.Command {
+	prune := &bundlePruneCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "delete unreferenced bundles from the local storage",
+		Long:  bundlePruneDesc,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			prune.home = home.Home(homePath())
+			return prune.run()
+		},
+	}
+	f := cmd.Flags()
+	f.BoolVar(&prune.keepClaimed, "keep-claimed", false, "keep bundles referenced by existing claims")
+	f.BoolVar(&prune.dryRun, "dry-run", false, "list the bundles that would be deleted without deleting them")
+
+	return cmd
+}
+
+func (p *bundlePruneCmd) run() error {
+	lock, err := repo.LockIndex(p.home.Repositories())
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	index, err := repo.LoadIndex(p.home.Repositories())
+	if err != nil {
+		return err
+	}
+
+	keep := map[string]bool{}
+	for _, versions := range index {
+		for _, d := range versions {
+			keep[filepath.Clean(p.home.BundleFile(d))] = true
+		}
+	}
+
+	if p.keepClaimed {
+		claims, err := claimStorage().ReadAll()
+		if err != nil {
+			return fmt.Errorf("cannot read claims: %v", err)
+		}
+		for _, c := range claims {
+			if c.Bundle == nil {
+				continue
+			}
+			_, d, err := marshalBundle(c.Bundle)
+			if err != nil {
+				return fmt.Errorf("cannot compute digest of the bundle for claim %q: %v", c.Name, err)
+			}
+			keep[filepath.Clean(p.home.BundleFile(d))] = true
+		}
+	}
+
+	pruned := 0
+	err = filepath.Walk(p.home.Bundles(), func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || keep[filepath.Clean(path)] {
+			return nil
+		}
+		pruned++
+		if p.dryRun {
+			fmt.Fprintf(p.out, "Would delete %s\n", path)
+			return nil
+		}
+		fmt.Fprintf(p.out, "Deleted %s\n", path)
+		return os.Remove(path)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if pruned == 0 {
+		fmt.Fprintln(p.out, "Nothing to prune.")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+func storeTestBundle(t *testing.T, name, version string) (*bundle.Bundle, string) {
+	t.Helper()
+	bun := &bundle.Bundle{
+		SchemaVersion: "v1.0.0-WD",
+		Name:          name,
+		Version:       version,
+	}
+	data, d, err := marshalBundle(bun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storeBundle(home.Home(homePath()), d, data); err != nil {
+		t.Fatal(err)
+	}
+	return bun, d
+}
+
+func TestBundlePrune(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	_, indexed := storeTestBundle(t, "foo", "1.0.0")
+	is.NoError(recordBundleReference(testHome, "foo", "1.0.0", indexed))
+	_, orphaned := storeTestBundle(t, "foo", "0.1.0")
+	claimed, claimedDigest := storeTestBundle(t, "bar", "1.0.0")
+	leftover := filepath.Join(testHome.Bundles(), "leftover.json")
+	is.NoError(ioutil.WriteFile(leftover, []byte("{}"), 0644))
+
+	c, err := claim.New("bar")
+	is.NoError(err)
+	c.Bundle = claimed
+	is.NoError(claimStorage().Store(*c))
+
+	out := bytes.NewBuffer(nil)
+	cmd := &bundlePruneCmd{home: testHome, out: out, keepClaimed: true}
+	is.NoError(cmd.run())
+	is.FileExists(testHome.BundleFile(indexed))
+	is.FileExists(testHome.BundleFile(claimedDigest))
+	is.False(fileExists(testHome.BundleFile(orphaned)))
+	is.False(fileExists(leftover))
+	is.Contains(out.String(), "Deleted "+leftover)
+
+	out.Reset()
+	cmd = &bundlePruneCmd{home: testHome, out: out, dryRun: true}
+	is.NoError(cmd.run())
+	is.Contains(out.String(), "Would delete "+testHome.BundleFile(claimedDigest))
+	is.FileExists(testHome.BundleFile(claimedDigest))
+
+	cmd = &bundlePruneCmd{home: testHome, out: out}
+	is.NoError(cmd.run())
+	is.FileExists(testHome.BundleFile(indexed))
+	is.False(fileExists(testHome.BundleFile(claimedDigest)))
+
+	out.Reset()
+	is.NoError(cmd.run())
+	is.Equal("Nothing to prune.\n", out.String())
+}
+
+func TestBundlePruneKeepsBundlesBeingAdded(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n := 0; n < 20; n++ {
+			bun := &bundle.Bundle{SchemaVersion: "v1.0.0-WD", Name: "foo", Version: fmt.Sprintf("1.0.%d", n)}
+			data, d, err := marshalBundle(bun)
+			is.NoError(err)
+			is.NoError(addBundle(testHome, bun.Name, bun.Version, d, data))
+		}
+	}()
+	for pruning := true; pruning; {
+		select {
+		case <-done:
+			pruning = false
+		default:
+		}
+		is.NoError((&bundlePruneCmd{home: testHome, out: ioutil.Discard}).run())
+	}
+
+	index, err := repo.LoadIndex(testHome.Repositories())
+	is.NoError(err)
+	versions, ok := index.GetVersions("foo")
+	is.True(ok)
+	is.Len(versions, 20)
+	for _, v := range versions {
+		is.FileExists(testHome.BundleFile(v.Digest), "a bundle is never pruned between being stored and being recorded")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 
 	"github.com/cnabio/duffle/pkg/duffle/home"
 	"github.com/cnabio/duffle/pkg/repo"
@@ -52,6 +51,12 @@ func newBundleRemoveCmd(w io.Writer) *cobra.Command {
 }
 
 func (rm *bundleRemoveCmd) run() error {
+	lock, err := repo.LockIndex(rm.home.Repositories())
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
 	index, err := repo.LoadIndex(rm.home.Repositories())
 	if err != nil {
 		return err
@@ -109,10 +114,16 @@ func (rm *bundleRemoveCmd) run() error {
 
 // deleteBundleVersions removes the given SHAs from bundle storage
 //
+// Bundles that are still referenced by other entries in the index are kept.
 // It warns, but does not fail, if a given SHA is not found.
 func deleteBundleVersions(vers []repo.BundleVersion, index repo.Index, h home.Home, w io.Writer) {
+	removed := map[string]bool{}
 	for _, ver := range vers {
-		fpath := filepath.Join(h.Bundles(), ver.Digest)
+		if removed[ver.Digest] || index.References(ver.Digest) > 0 {
+			continue
+		}
+		removed[ver.Digest] = true
+		fpath := h.BundleFile(ver.Digest)
 		if err := os.Remove(fpath); err != nil {
 			fmt.Fprintf(w, "WARNING: could not delete stake record %q", fpath)
 		}
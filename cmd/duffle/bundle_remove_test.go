@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/cnabio/duffle/pkg/duffle/home"
@@ -37,3 +39,28 @@ func TestBundleRemove(t *testing.T) {
 		t.Errorf("Expected bundle file to be removed from local store but was not")
 	}
 }
+
+func TestSearchLocalSkipsMissingBundles(t *testing.T) {
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	_, d := storeTestBundle(t, "foo", "1.0.0")
+	if err := recordBundleReference(testHome, "foo", "1.0.0", d); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordBundleReference(testHome, "foo", "1.1.0", "sha256:"+strings.Repeat("a", 64)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	refs, err := searchLocal(testHome, out)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	if len(refs) != 1 || refs[0].Tag() != "1.0.0" {
+		t.Errorf("Expected only foo:1.0.0 to be listed, got %v", refs)
+	}
+	if !strings.Contains(out.String(), "skipping foo:1.1.0") {
+		t.Errorf("Expected a warning about foo:1.1.0, got %q", out.String())
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 
@@ -11,9 +12,10 @@ import (
 const bundleShowShortUsage = `return low-level information on application bundles`
 
 type bundleShowCmd struct {
-	name string
-	raw  bool
-	w    io.Writer
+	name   string
+	raw    bool
+	output outputFormat
+	w      io.Writer
 }
 
 func newBundleShowCmd(w io.Writer) *cobra.Command {
@@ -34,6 +36,7 @@ func newBundleShowCmd(w io.Writer) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.BoolVarP(&bsc.raw, "raw", "r", false, "Display the raw bundle manifest")
+	bsc.output.addShowFlag(flags)
 
 	return cmd
 }
@@ -53,6 +56,12 @@ func (bsc *bundleShowCmd) usage(bundleSubCommand bool) string {
 }
 
 func (bsc *bundleShowCmd) run() error {
+	if bsc.raw && !bsc.output.table() {
+		return errors.New("--raw and --output cannot be used together")
+	}
+	if err := bsc.output.validate(); err != nil {
+		return err
+	}
 	bundleFile, err := getBundleFilepath(bsc.name, homePath())
 	if err != nil {
 		return err
@@ -68,11 +77,12 @@ func (bsc *bundleShowCmd) run() error {
 		return err
 	}
 
-	d, err := json.MarshalIndent(bun, " ", " ")
-	if err != nil {
+	return bsc.output.print(bsc.w, bun, func() error {
+		d, err := json.MarshalIndent(bun, " ", " ")
+		if err != nil {
+			return err
+		}
+		_, err = bsc.w.Write(d)
 		return err
-	}
-	_, err = bsc.w.Write(d)
-
-	return err
+	})
 }
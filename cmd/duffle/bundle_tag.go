@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Masterminds/semver"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/reference"
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+const bundleTagDesc = `Add a name or version that refers to a bundle in the local storage.
+
+The new name points at the same stored bundle as SOURCE, which may be a name, a name and
+version, or a name pinned to a digest. If TARGET does not include a version, the version
+of the source bundle is used. Versions must be SemVer2 versions.
+
+Ex. $ duffle bundle tag foo:1.0.0 bar:1.0.0  # bar:1.0.0 now refers to the same bundle as foo:1.0.0
+    $ duffle bundle tag foo@sha256:<digest> foo:1.0.1
+
+Removing either name with 'duffle bundle remove' keeps the bundle as long as another name refers to it.
+`
+
+type bundleTagCmd struct {
+	home   home.Home
+	out    io.Writer
+	source string
+	target string
+}
+
+func newBundleTagCmd(w io.Writer) *cobra.Command {
+	tag := &bundleTagCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "tag SOURCE TARGET",
+		Short: "add a name or version for a bundle in the local storage",
+		Long:  bundleTagDesc,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tag.source = args[0]
+			tag.target = args[1]
+			tag.home = home.Home(homePath())
+			return tag.run()
+		},
+	}
+
+	return cmd
+}
+
+func (t *bundleTagCmd) run() error {
+	src, err := getReference(t.source)
+	if err != nil {
+		return fmt.Errorf("could not parse reference for %s: %v", t.source, err)
+	}
+	dst, err := reference.ParseNormalizedNamed(t.target)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid bundle name: %v", t.target, err)
+	}
+	if _, ok := dst.(reference.Digested); ok {
+		return errors.New("the target of a tag cannot be a digest")
+	}
+
+	return repo.UpdateIndex(t.home.Repositories(), 0644, func(index repo.Index) error {
+		digest, err := lookupDigest(index, src)
+		if err != nil {
+			return fmt.Errorf("could not find %s in %s: %v", src, t.home.Repositories(), err)
+		}
+
+		var version string
+		if tagged, ok := dst.(reference.Tagged); ok {
+			version = tagged.Tag()
+		} else {
+			bun, err := loadBundle(t.home.BundleFile(digest))
+			if err != nil {
+				return err
+			}
+			version = bun.Version
+		}
+		if _, err := semver.NewVersion(version); err != nil {
+			return fmt.Errorf("%q is not a valid SemVer2 version: %v", version, err)
+		}
+
+		if existing, ok := index[dst.Name()][version]; ok && existing != digest {
+			return fmt.Errorf("%s:%s already refers to a different bundle (%s); remove it first with 'duffle bundle remove %s --version %s'", dst.Name(), version, existing, dst.Name(), version)
+		}
+		index.Add(dst.Name(), version, digest)
+		fmt.Fprintf(t.out, "Tagged %s as %s:%s\n", digest, dst.Name(), version)
+		return nil
+	})
+}
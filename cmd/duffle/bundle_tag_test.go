@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+func TestBundleTag(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	_, d := storeTestBundle(t, "foo", "1.0.0")
+	is.NoError(recordBundleReference(testHome, "foo", "1.0.0", d))
+	_, other := storeTestBundle(t, "foo", "2.0.0")
+	is.NoError(recordBundleReference(testHome, "foo", "2.0.0", other))
+
+	out := bytes.NewBuffer(nil)
+	tag := func(src, dst string) error {
+		cmd := &bundleTagCmd{home: testHome, out: out, source: src, target: dst}
+		return cmd.run()
+	}
+
+	is.NoError(tag("foo:1.0.0", "bar:1.2.3"))
+	is.Contains(out.String(), "Tagged "+d+" as bar:1.2.3")
+	is.NoError(tag("foo@"+d, "baz"), "the version of the source bundle is used")
+	is.NoError(tag("foo:1.0.0", "bar:1.2.3"), "re-tagging the same bundle is a no-op")
+
+	is.EqualError(tag("foo:1.0.0", "bar:latest"), `"latest" is not a valid SemVer2 version: Invalid Semantic Version`)
+	is.EqualError(tag("foo:1.0.0", "bar@"+other), "the target of a tag cannot be a digest")
+	err := tag("foo:2.0.0", "bar:1.2.3")
+	is.Error(err)
+	is.Contains(err.Error(), "bar:1.2.3 already refers to a different bundle")
+	is.Error(tag("nope", "bar:1.0.0"))
+
+	index, err := repo.LoadIndex(testHome.Repositories())
+	is.NoError(err)
+	is.Equal(map[string]string{"1.2.3": d}, index["bar"])
+	is.Equal(map[string]string{"1.0.0": d}, index["baz"])
+
+	// the tagged bundle can be installed, shown and exported by its new name
+	file, err := getBundleFilepath("bar:1.2.3", testHome.String())
+	is.NoError(err)
+	is.Equal(testHome.BundleFile(d), file)
+	file, err = getBundleFilepath("bar@"+d, testHome.String())
+	is.NoError(err)
+	is.Equal(testHome.BundleFile(d), file)
+
+	// removing one of the names keeps the bundle for the other names
+	rm := &bundleRemoveCmd{home: testHome, out: out, bundleRef: "bar"}
+	is.NoError(rm.run())
+	is.FileExists(testHome.BundleFile(d))
+}
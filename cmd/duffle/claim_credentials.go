@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/cnabio/cnab-go/claim"
+)
+
+// claimCustomKey is the key of the data Duffle keeps in the custom section of a claim.
+const claimCustomKey = "duffle"
+
+// claimData is the data Duffle keeps in the custom section of a claim.
+type claimData struct {
+	// CredentialSets are the names of the credential sets, or the paths of the credential set files, the installation
+	// was last installed or upgraded with. The values of the credentials are never stored.
+	CredentialSets []string `json:"credentialSets,omitempty"`
+	// Dependencies are the names of the installations of the dependencies of the bundle, in the order they were
+	// installed in.
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// readClaimData returns the data Duffle keeps in the claim.
+func readClaimData(c claim.Claim) claimData {
+	var data claimData
+	custom, ok := c.Custom.(map[string]interface{})
+	if !ok {
+		return data
+	}
+	raw, err := json.Marshal(custom[claimCustomKey])
+	if err != nil {
+		return data
+	}
+	json.Unmarshal(raw, &data)
+	return data
+}
+
+// writeClaimData stores data in the claim, keeping anything else in its custom section.
+func writeClaimData(c *claim.Claim, data claimData) {
+	custom := map[string]interface{}{}
+	switch existing := c.Custom.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, v := range existing {
+			custom[k] = v
+		}
+	default:
+		// the custom section belongs to someone else
+		return
+	}
+	custom[claimCustomKey] = data
+	c.Custom = custom
+}
+
+// claimCredentialSets returns the credential sets to use for an action on the installation: the given ones, or, if
+// none are given, the ones the installation was installed or upgraded with.
+func claimCredentialSets(c claim.Claim, given []string) []string {
+	if len(given) > 0 {
+		return given
+	}
+	return readClaimData(c).CredentialSets
+}
+
+// rememberCredentialSets records the credential sets used for the installation in the claim, unless none were given.
+// Paths of credential set files are made absolute, so that they can be found from any directory.
+func rememberCredentialSets(c *claim.Claim, given []string) {
+	if len(given) == 0 {
+		return
+	}
+	sets := make([]string, len(given))
+	for i, set := range given {
+		sets[i] = set
+		if fileExists(set) {
+			if abs, err := filepath.Abs(set); err == nil {
+				sets[i] = abs
+			}
+		}
+	}
+	data := readClaimData(*c)
+	data.CredentialSets = sets
+	writeClaimData(c, data)
+}
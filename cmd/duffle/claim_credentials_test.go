@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialSetsAreRemembered(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	data, err := json.Marshal(dryRunTestBundle())
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	for _, name := range []string{"first", "second"} {
+		data, err = yaml.Marshal(credentials.CredentialSet{
+			Name: name,
+			Credentials: []credentials.CredentialStrategy{
+				{Name: "token", Source: credentials.Source{Value: name + "-s3cr3t"}},
+			},
+		})
+		is.NoError(err)
+		is.NoError(ioutil.WriteFile(filepath.Join(testHome.Credentials(), name+".yaml"), data, 0600))
+	}
+	firstFile := filepath.Join(testHome.Credentials(), "first.yaml")
+
+	install := &installCmd{
+		bundle:           bundleFile,
+		bundleIsFile:     true,
+		name:             "remembered",
+		home:             testHome,
+		out:              ioutil.Discard,
+		driver:           "debug",
+		credentialsFiles: []string{firstFile},
+	}
+	is.NoError(install.run())
+	c, err := claimStorage().Read("remembered")
+	is.NoError(err)
+	is.Equal([]string{firstFile}, readClaimData(c).CredentialSets)
+
+	raw, err := ioutil.ReadFile(filepath.Join(testHome.Claims(), "remembered.json"))
+	is.NoError(err)
+	is.NotContains(string(raw), "s3cr3t", "only the names of the credential sets are stored")
+
+	out := bytes.NewBuffer(nil)
+	upgrade := &upgradeCmd{name: "remembered", out: out, driver: "debug"}
+	is.NoError(upgrade.run())
+	is.Contains(out.String(), `"TOKEN": "*****"`, "the credential sets of the install are reused")
+
+	upgrade = &upgradeCmd{name: "remembered", out: ioutil.Discard, driver: "debug", credentialsFiles: []string{"second"}}
+	is.NoError(upgrade.run())
+	c, err = claimStorage().Read("remembered")
+	is.NoError(err)
+	is.Equal([]string{"second"}, readClaimData(c).CredentialSets, "given credential sets replace the remembered ones")
+
+	out.Reset()
+	is.NoError((&listCmd{out: out}).run())
+	is.Regexp(`remembered\s.*\ssecond`, out.String())
+
+	out.Reset()
+	uninstall := &uninstallCmd{name: "remembered", out: out, driver: "debug"}
+	is.NoError(uninstall.run())
+	is.Contains(out.String(), `"TOKEN": "*****"`)
+}
+
+func TestWriteClaimDataKeepsCustomData(t *testing.T) {
+	is := assert.New(t)
+
+	c := claim.Claim{Custom: map[string]interface{}{"other": "tool"}}
+	rememberCredentialSets(&c, []string{"prod"})
+	is.Equal("tool", c.Custom.(map[string]interface{})["other"])
+	is.Equal([]string{"prod"}, readClaimData(c).CredentialSets)
+
+	c = claim.Claim{Custom: "not ours"}
+	rememberCredentialSets(&c, []string{"prod"})
+	is.Equal("not ours", c.Custom)
+	is.Empty(claimCredentialSets(c, nil))
+}
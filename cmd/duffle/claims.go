@@ -26,6 +26,8 @@ func newClaimsCmd(w io.Writer) *cobra.Command {
 
 	cmd.AddCommand(newClaimsShowCmd(w))
 	cmd.AddCommand(newClaimListCmd(w))
+	cmd.AddCommand(newClaimsDeleteCmd(w))
+	cmd.AddCommand(newClaimsUnlockCmd(w))
 
 	return cmd
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/spf13/cobra"
+)
+
+const claimsDeleteDesc = `
+Delete the claim of an installation, without running any action.
+
+Use this to forget installations that cannot be uninstalled, for example because their
+invocation image no longer exists. The resources of the installation are not removed.
+The logs of the installation are kept, and can still be shown with 'duffle logs'.
+`
+
+type claimsDeleteCmd struct {
+	name string
+	out  io.Writer
+}
+
+func newClaimsDeleteCmd(w io.Writer) *cobra.Command {
+	del := &claimsDeleteCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:     "delete NAME",
+		Aliases: []string{"rm"},
+		Short:   "delete the claim of an installation without running anything",
+		Long:    claimsDeleteDesc,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			del.name = args[0]
+			return del.run()
+		},
+	}
+
+	return cmd
+}
+
+func (d *claimsDeleteCmd) run() error {
+	lease, err := lockClaim(d.name, "delete")
+	if err != nil {
+		return err
+	}
+	defer unlockClaim(d.out, lease)
+
+	storage := claimStorage()
+	if _, err := storage.Read(d.name); err == claim.ErrClaimNotFound {
+		return fmt.Errorf("Bundle installation '%s' not found", d.name)
+	} else if err != nil {
+		return err
+	}
+	if err := storage.Delete(d.name); err != nil {
+		return err
+	}
+	fmt.Fprintf(d.out, "Deleted claim %s\n", d.name)
+	return nil
+}
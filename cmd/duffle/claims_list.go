@@ -14,13 +14,14 @@ func newClaimListCmd(out io.Writer) *cobra.Command {
 		Aliases: []string{"ls"},
 		Short:   "list available claims",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			l := &listCmd{out: out, short: list.short}
+			l := &listCmd{out: out, short: list.short, output: list.output}
 			return l.run()
 		},
 	}
 
 	f := cmd.Flags()
 	f.BoolVarP(&list.short, "short", "s", false, "output shorter listing format")
+	list.output.addFlag(f)
 
 	return cmd
 }
@@ -5,23 +5,34 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/cnabio/cnab-go/claim"
 
 	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/ohai"
 )
 
 const claimsShowDesc = `
 Display the content of a claim.
 
-This dumps the entire content of a claim as a JSON object.
+This dumps the entire content of a claim as a JSON object, or in the format set with
+'-o yaml' or '-o jsonpath=...'. The values of write-only parameters and outputs are masked
+unless '--show-secrets' is set. They are stored encrypted with a key in the Duffle home,
+so they can only be shown with that key.
+
+With '--output-name', only the contents of the named output are shown. Selecting the output
+with '-o NAME' is deprecated: it still works for names that are not output formats.
 `
 
 type claimsShowCmd struct {
-	Name       string
-	OnlyBundle bool
-	Output     string
-	Storage    claim.Store
+	Name        string
+	OnlyBundle  bool
+	OutputName  string
+	Format      outputFormat
+	ShowSecrets bool
+	Storage     claim.Store
 }
 
 func newClaimsShowCmd(w io.Writer) *cobra.Command {
@@ -33,6 +44,7 @@ func newClaimsShowCmd(w io.Writer) *cobra.Command {
 		Aliases: []string{"get"},
 		Args:    cobra.ExactArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			cmdData.resolveDeprecatedOutput(cmd.ErrOrStderr())
 			return cmdData.validateShowFlags()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -43,16 +55,31 @@ func newClaimsShowCmd(w io.Writer) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&cmdData.OnlyBundle, "bundle", "b", false, "only show the bundle from the claim")
-	cmd.Flags().StringVarP(&cmdData.Output, "output", "o", "", "show the contents of the named output")
+	cmd.Flags().StringVar(&cmdData.OutputName, "output-name", "", "show the contents of the named output")
+	cmdData.Format.addShowFlag(cmd.Flags())
+	cmd.Flags().BoolVar(&cmdData.ShowSecrets, "show-secrets", false, "show the values of write-only parameters and outputs")
 
 	return cmd
 }
 
+// resolveDeprecatedOutput treats the value of --output as the name of an output if it is not an output format, as
+// --output selected an output before it selected the format.
+func (csc *claimsShowCmd) resolveDeprecatedOutput(w io.Writer) {
+	if csc.Format.validate() == nil || strings.HasPrefix(string(csc.Format), jsonPathPrefix) {
+		return
+	}
+	ohai.Fwarningf(w, "selecting an output with --output is deprecated, use --output-name %s\n", csc.Format)
+	csc.OutputName, csc.Format = string(csc.Format), ""
+}
+
 func (csc claimsShowCmd) validateShowFlags() error {
-	if csc.OnlyBundle && csc.Output != "" {
-		return errors.New("invalid flags: at most one of --bundle and --output can be specified")
+	if csc.OnlyBundle && csc.OutputName != "" {
+		return errors.New("invalid flags: at most one of --bundle and --output-name can be specified")
+	}
+	if csc.OutputName != "" && !csc.Format.table() {
+		return errors.New("invalid flags: --output-name prints the contents of an output, and cannot be used with --output")
 	}
-	return nil
+	return csc.Format.validate()
 }
 
 func (csc claimsShowCmd) runClaimShow(w io.Writer) error {
@@ -62,20 +89,24 @@ func (csc claimsShowCmd) runClaimShow(w io.Writer) error {
 	}
 
 	if csc.OnlyBundle {
-		return displayAsJSON(w, c.Bundle)
+		return csc.Format.print(w, c.Bundle, func() error { return displayAsJSON(w, c.Bundle) })
+	}
+
+	if !csc.ShowSecrets {
+		c = maskClaim(c)
 	}
 
-	if csc.Output != "" {
-		output, found := c.Outputs[csc.Output]
+	if csc.OutputName != "" {
+		output, found := c.Outputs[csc.OutputName]
 		if !found {
-			return fmt.Errorf("unknown output name: %s", csc.Output)
+			return fmt.Errorf("unknown output name: %s", csc.OutputName)
 		}
 
 		_, err := fmt.Fprint(w, output)
 		return err
 	}
 
-	return displayAsJSON(w, c)
+	return csc.Format.print(w, c, func() error { return displayAsJSON(w, c) })
 }
 
 func displayAsJSON(out io.Writer, v interface{}) error {
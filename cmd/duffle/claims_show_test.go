@@ -114,9 +114,9 @@ func TestRunClaimShow_Output(t *testing.T) {
 
 	t.Run("happy path", func(t *testing.T) {
 		csc := claimsShowCmd{
-			Name:    "myclaim",
-			Output:  "some-output",
-			Storage: mockClaimStore(),
+			Name:       "myclaim",
+			OutputName: "some-output",
+			Storage:    mockClaimStore(),
 		}
 		csc.Storage.Store(expectedClaim)
 
@@ -125,11 +125,11 @@ func TestRunClaimShow_Output(t *testing.T) {
 		assert.Equal(t, expectedClaim.Outputs["some-output"], buf.String())
 	})
 
-	t.Run("error case: when --output name is an unknown output", func(t *testing.T) {
+	t.Run("error case: when --output-name is an unknown output", func(t *testing.T) {
 		csc := claimsShowCmd{
-			Name:    "myclaim",
-			Output:  "not-an-output",
-			Storage: mockClaimStore(),
+			Name:       "myclaim",
+			OutputName: "not-an-output",
+			Storage:    mockClaimStore(),
 		}
 		csc.Storage.Store(expectedClaim)
 
@@ -139,17 +139,44 @@ func TestRunClaimShow_Output(t *testing.T) {
 
 }
 
-//error case: when --bundle and --output are both specified
+//error case: when --bundle and --output-name are both specified
 func TestRunClaimShow_InvalidFlags(t *testing.T) {
 	csc := claimsShowCmd{
 		Name:       "myclaim",
-		Output:     "some-output",
+		OutputName: "some-output",
 		OnlyBundle: true,
 		Storage:    mockClaimStore(),
 	}
 
 	err := csc.validateShowFlags()
-	assert.EqualError(t, err, "invalid flags: at most one of --bundle and --output can be specified")
+	assert.EqualError(t, err, "invalid flags: at most one of --bundle and --output-name can be specified")
+}
+
+func TestRunClaimShow_Format(t *testing.T) {
+	var buf bytes.Buffer
+	csc := claimsShowCmd{Name: "myclaim", Format: "jsonpath=.bundle.version", Storage: mockClaimStore()}
+	csc.Storage.Store(claim.Claim{Name: "myclaim", Bundle: &bundle.Bundle{Name: "mybundle", Version: "0.1.2"}})
+
+	assert.NoError(t, csc.validateShowFlags())
+	assert.NoError(t, csc.runClaimShow(&buf))
+	assert.Equal(t, "0.1.2\n", buf.String())
+
+	csc.OutputName = "some-output"
+	assert.EqualError(t, csc.validateShowFlags(), "invalid flags: --output-name prints the contents of an output, and cannot be used with --output")
+}
+
+// -o NAME still selects an output, as it did before -o selected the format
+func TestRunClaimShow_DeprecatedOutput(t *testing.T) {
+	var warnings bytes.Buffer
+	csc := claimsShowCmd{Name: "myclaim", Format: "some-output"}
+	csc.resolveDeprecatedOutput(&warnings)
+	assert.Equal(t, "some-output", csc.OutputName)
+	assert.True(t, csc.Format.table())
+	assert.Contains(t, warnings.String(), "use --output-name some-output")
+
+	csc = claimsShowCmd{Name: "myclaim", Format: formatYAML}
+	csc.resolveDeprecatedOutput(&warnings)
+	assert.Equal(t, "", csc.OutputName, "output formats are not output names")
 }
 
 func TestDisplayJSON(t *testing.T) {
@@ -199,3 +226,37 @@ type mockWriter struct {
 func (mw mockWriter) Write([]byte) (int, error) {
 	return 0, mw.err
 }
+
+func TestRunClaimShow_Secrets(t *testing.T) {
+	c := claim.Claim{
+		Name:       "secret",
+		Bundle:     secretTestBundle(),
+		Parameters: map[string]interface{}{"user": "admin", "password": "hunter2"},
+		Outputs:    map[string]interface{}{"token": "t0k3n"},
+	}
+
+	t.Run("masked by default", func(t *testing.T) {
+		var buf bytes.Buffer
+		csc := claimsShowCmd{Name: "secret", Storage: mockClaimStore()}
+		csc.Storage.Store(c)
+
+		assert.NoError(t, csc.runClaimShow(&buf))
+		assert.NotContains(t, buf.String(), "hunter2")
+		assert.NotContains(t, buf.String(), "t0k3n")
+
+		buf.Reset()
+		csc.OutputName = "token"
+		assert.NoError(t, csc.runClaimShow(&buf))
+		assert.Equal(t, "*****", buf.String())
+	})
+
+	t.Run("with --show-secrets", func(t *testing.T) {
+		var buf bytes.Buffer
+		csc := claimsShowCmd{Name: "secret", ShowSecrets: true, Storage: mockClaimStore()}
+		csc.Storage.Store(c)
+
+		assert.NoError(t, csc.runClaimShow(&buf))
+		assert.Contains(t, buf.String(), `"password": "hunter2"`)
+		assert.Contains(t, buf.String(), `"token": "t0k3n"`)
+	})
+}
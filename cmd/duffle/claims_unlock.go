@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/claimlock"
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/ohai"
+)
+
+const claimsUnlockDesc = `
+Remove the lock on an installation.
+
+Install, upgrade, uninstall and custom actions that modify an installation lock it while they
+run, so that two actions cannot run on the same installation at the same time. The lock is
+renewed while the action runs, and expires a few minutes after its holder stops, for example
+because it was killed.
+
+A lock that has expired is removed without '--force'. A lock that is still held can only be
+removed with '--force'; make sure its holder is no longer running first.
+`
+
+type claimsUnlockCmd struct {
+	name  string
+	force bool
+	home  home.Home
+	out   io.Writer
+}
+
+func newClaimsUnlockCmd(w io.Writer) *cobra.Command {
+	unlock := &claimsUnlockCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "unlock NAME",
+		Short: "remove the lock on an installation",
+		Long:  claimsUnlockDesc,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			unlock.name = args[0]
+			unlock.home = home.Home(homePath())
+			return unlock.run()
+		},
+	}
+	cmd.Flags().BoolVar(&unlock.force, "force", false, "remove the lock even if it is still held")
+
+	return cmd
+}
+
+func (u *claimsUnlockCmd) run() error {
+	locker := claimLocker(u.home)
+	info, err := locker.Read(u.name)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		fmt.Fprintf(u.out, "%s is not locked\n", u.name)
+		return nil
+	}
+	if !info.Expired(time.Now()) && !u.force {
+		return fmt.Errorf("%s is locked by %s until %s; use --force to remove the lock anyway", u.name, info.Holder, info.Expires.Format(time.RFC3339))
+	}
+	if _, err := locker.Break(u.name); err != nil {
+		return err
+	}
+	fmt.Fprintf(u.out, "Removed the lock on %s held by %s\n", u.name, info.Holder)
+	return nil
+}
+
+// claimLocker returns the locker for the installations in the Duffle home.
+func claimLocker(h home.Home) *claimlock.Locker {
+	return claimlock.New(claimlock.NewFileBackend(filepath.Join(h.Locks(), "claims")))
+}
+
+// lockClaim locks the named installation for an action, failing if another action holds the lock.
+func lockClaim(name, action string) (*claimlock.Lease, error) {
+	lease, err := claimLocker(home.Home(homePath())).Acquire(name, action)
+	if _, ok := err.(*claimlock.LockedError); ok {
+		return nil, fmt.Errorf("cannot %s: %v (if it is no longer running, remove the lock with 'duffle claims unlock %s --force')", action, err, name)
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot lock %q: %v", name, err)
+	}
+	return lease, nil
+}
+
+// unlockClaim releases the lock taken by lockClaim, warning if it was broken while the action ran. The lock is kept if
+// Duffle stopped waiting for the operation of the action, which may still be running.
+func unlockClaim(w io.Writer, lease *claimlock.Lease) {
+	name := lease.Info().Name
+	if _, ok := abandonedOperations.Load(name); ok {
+		lease.Abandon()
+		ohai.Fwarningf(w, "%q stays locked because its operation may still be running; once it has stopped, remove the lock with 'duffle claims unlock %s --force'\n", name, name)
+		return
+	}
+	if err := lease.Release(); err != nil {
+		ohai.Fwarningf(w, "could not release the lock on %q: %v\n", lease.Info().Name, err)
+	}
+}
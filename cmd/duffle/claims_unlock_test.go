@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimLocking(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	data, err := json.Marshal(dryRunTestBundle())
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	install := &installCmd{bundle: bundleFile, bundleIsFile: true, name: "locked", home: testHome, out: ioutil.Discard, driver: "debug"}
+	is.NoError(install.run())
+	info, err := claimLocker(testHome).Read("locked")
+	is.NoError(err)
+	is.Nil(info, "the lock is released after the install")
+
+	lease, err := lockClaim("locked", "upgrade")
+	is.NoError(err)
+
+	err = (&upgradeCmd{name: "locked", out: ioutil.Discard, driver: "debug"}).run()
+	is.Error(err)
+	is.Contains(err.Error(), `cannot upgrade: "locked" is locked by`)
+	is.Contains(err.Error(), "duffle claims unlock locked --force")
+
+	err = (&uninstallCmd{name: "locked", out: ioutil.Discard, driver: "debug", dryRun: true}).run()
+	is.NoError(err, "dry runs do not need the lock")
+
+	out := bytes.NewBuffer(nil)
+	err = (&claimsUnlockCmd{name: "locked", home: testHome, out: out}).run()
+	is.Error(err)
+	is.Contains(err.Error(), "use --force to remove the lock anyway")
+
+	is.NoError((&claimsUnlockCmd{name: "locked", home: testHome, out: out, force: true}).run())
+	is.Contains(out.String(), "Removed the lock on locked held by")
+
+	is.NoError((&upgradeCmd{name: "locked", out: ioutil.Discard, driver: "debug"}).run())
+
+	warnings := bytes.NewBuffer(nil)
+	unlockClaim(warnings, lease)
+	is.Contains(warnings.String(), `could not release the lock on "locked": the lock was broken while it was held`)
+
+	out.Reset()
+	is.NoError((&claimsUnlockCmd{name: "locked", home: testHome, out: out}).run())
+	is.Equal("locked is not locked\n", out.String())
+}
+
+func TestUnlockClaimKeepsLockOfAbandonedOperation(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	lease, err := lockClaim("abandoned", "install")
+	is.NoError(err)
+	abandonedOperations.Store("abandoned", true)
+	defer abandonedOperations.Delete("abandoned")
+
+	out := bytes.NewBuffer(nil)
+	unlockClaim(out, lease)
+	is.Contains(out.String(), "duffle claims unlock abandoned --force")
+	_, err = lockClaim("abandoned", "upgrade")
+	is.Error(err, "the lock is kept while the operation may still be running")
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/credprovider"
+	"github.com/cnabio/duffle/pkg/duffle/home"
+)
+
+const credentialCheckDesc = `
+Check that credential sets satisfy the credentials of a bundle, before running an action.
+
+For each credential of the bundle, the report shows the credential set and source it is taken
+from, and whether the source currently resolves: environment variables must be set, files must be
+readable, commands must be found, and credentials from a provider are fetched. Commands are not run.
+
+As with 'duffle install', when several credential sets provide a credential, the last one wins.
+Credentials that do not apply to the action (see '--action') are skipped.
+
+The command fails if a required credential is missing, or if a credential's source does not resolve,
+so it can be used to check credentials in CI:
+
+	$ duffle credentials check production shared mybundle:1.0.0
+	$ duffle credentials check production -f bundle.json --action upgrade
+`
+
+// The statuses of a credential in the report of duffle credentials check.
+const (
+	credentialOK         = "ok"
+	credentialMissing    = "missing"
+	credentialUnresolved = "unresolved"
+	credentialSkipped    = "skipped"
+)
+
+type credentialCheckCmd struct {
+	sets       []string
+	bundle     string
+	bundleFile string
+	action     string
+	output     outputFormat
+	home       home.Home
+	out        io.Writer
+}
+
+// credentialCheck is the result of checking one credential of a bundle.
+type credentialCheck struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Set      string `json:"set,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Status   string `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// failed reports whether the credential keeps the action from running.
+func (c credentialCheck) failed() bool {
+	return c.Status == credentialUnresolved || (c.Status == credentialMissing && c.Required)
+}
+
+func newCredentialCheckCmd(w io.Writer) *cobra.Command {
+	check := &credentialCheckCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "check SET... [BUNDLE]",
+		Short: "check that credential sets satisfy the credentials of a bundle",
+		Long:  credentialCheckDesc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case check.bundleFile == "" && len(args) < 2:
+				return errors.New("required arguments are one or more credential sets and a BUNDLE (CNAB bundle name) or file (using -f)")
+			case check.bundleFile == "":
+				check.sets, check.bundle = args[:len(args)-1], args[len(args)-1]
+			case len(args) < 1:
+				return errors.New("required arguments are one or more credential sets")
+			default:
+				check.sets = args
+			}
+			check.home = home.Home(homePath())
+			return check.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&check.bundleFile, "file", "f", "", "path to bundle.json")
+	f.StringVar(&check.action, "action", claim.ActionInstall, "the action to check the credentials for")
+	check.output.addFlag(f)
+
+	return cmd
+}
+
+func (c *credentialCheckCmd) run() error {
+	if err := c.output.validate(); err != nil {
+		return err
+	}
+	bundleFile := c.bundleFile
+	if bundleFile == "" {
+		var err error
+		if bundleFile, err = getBundleFilepath(c.bundle, c.home.String()); err != nil {
+			return err
+		}
+	}
+	bun, err := loadBundle(bundleFile)
+	if err != nil {
+		return err
+	}
+	applyTo, err := credentialApplyTo(bundleFile)
+	if err != nil {
+		return err
+	}
+
+	checks, err := c.check(bun, applyTo)
+	if err != nil {
+		return err
+	}
+
+	err = c.output.print(c.out, checks, func() error {
+		table := uitable.New()
+		table.MaxColWidth = 80
+		table.Wrap = true
+		table.AddRow("CREDENTIAL", "REQUIRED", "SET", "SOURCE", "STATUS")
+		for _, check := range checks {
+			status := check.Status
+			if check.Detail != "" {
+				status += ": " + check.Detail
+			}
+			table.AddRow(check.Name, check.Required, check.Set, check.Source, status)
+		}
+		fmt.Fprintln(c.out, table)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, check := range checks {
+		if check.failed() {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d credentials of %s are not satisfied for %s", failed, len(checks), bun.Name, c.action)
+	}
+	return nil
+}
+
+// check returns the result of checking each credential of bun, sorted by name.
+func (c *credentialCheckCmd) check(bun *bundle.Bundle, applyTo map[string][]string) ([]credentialCheck, error) {
+	type provided struct {
+		set      string
+		strategy credentials.CredentialStrategy
+		provider *credprovider.Source
+	}
+	given := map[string]provided{}
+	for _, name := range c.sets {
+		data, err := readCredentialSet(findCreds(c.home.Credentials(), name))
+		if err != nil {
+			return nil, err
+		}
+		cs := &credentials.CredentialSet{}
+		if err := yaml.Unmarshal(data, cs); err != nil {
+			return nil, fmt.Errorf("credential set %s is malformed: %v", name, err)
+		}
+		sources, err := credprovider.ParseSources(data)
+		if err != nil {
+			return nil, fmt.Errorf("credential set %s: %v", name, err)
+		}
+		// last one wins, as in loadCredentials
+		for _, cred := range cs.Credentials {
+			p := provided{set: name, strategy: cred}
+			if src, ok := sources[cred.Name]; ok {
+				p.provider = &src
+			}
+			given[cred.Name] = p
+		}
+	}
+
+	names := make([]string, 0, len(bun.Credentials))
+	for name := range bun.Credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checks := make([]credentialCheck, 0, len(names))
+	for _, name := range names {
+		check := credentialCheck{Name: name, Required: bun.Credentials[name].Required}
+		p, ok := given[name]
+		switch {
+		case !appliesTo(applyTo[name], c.action):
+			check.Status = credentialSkipped
+			check.Detail = "does not apply to " + c.action
+		case !ok:
+			check.Status = credentialMissing
+		case p.provider != nil:
+			check.Set = p.set
+			check.Source = fmt.Sprintf("provider %s %s", p.provider.Provider, p.provider.Key)
+			check.Status, check.Detail = checkProviderSource(*p.provider)
+		default:
+			check.Set = p.set
+			check.Source, check.Status, check.Detail = checkSource(p.strategy.Source)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// checkSource describes src and reports whether it currently resolves, following the precedence of
+// credentials.CredentialSet.Resolve.
+func checkSource(src credentials.Source) (source, status, detail string) {
+	switch {
+	case src.Command != "":
+		source = "command " + src.Command
+		cmd := strings.Split(src.Command, " ")[0]
+		if _, err := exec.LookPath(cmd); err != nil {
+			return source, credentialUnresolved, fmt.Sprintf("%s was not found", cmd)
+		}
+		return source, credentialOK, ""
+	case src.Path != "":
+		source = "path " + src.Path
+		f, err := os.Open(os.ExpandEnv(src.Path))
+		if err != nil {
+			return source, credentialUnresolved, err.Error()
+		}
+		f.Close()
+		return source, credentialOK, ""
+	case src.EnvVar != "":
+		source = "env " + src.EnvVar
+		if _, ok := os.LookupEnv(src.EnvVar); ok {
+			return source, credentialOK, ""
+		}
+		if src.Value != "" {
+			return source, credentialOK, "not set, using the value"
+		}
+		return source, credentialUnresolved, src.EnvVar + " is not set"
+	default:
+		return "value", credentialOK, ""
+	}
+}
+
+// checkProviderSource reports whether the provider resolves the key of src.
+func checkProviderSource(src credprovider.Source) (status, detail string) {
+	p, err := credprovider.Default.Lookup(src.Provider)
+	if err != nil {
+		return credentialUnresolved, err.Error()
+	}
+	if _, err := p.Resolve(src.Key); err != nil {
+		return credentialUnresolved, err.Error()
+	}
+	return credentialOK, ""
+}
+
+// credentialApplyTo returns the actions each credential of the bundle in bundleFile applies to, by credential name.
+// bundle.Credential does not hold applyTo yet, so it is read from the bundle file.
+func credentialApplyTo(bundleFile string) (map[string][]string, error) {
+	data, err := ioutil.ReadFile(bundleFile)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Credentials map[string]struct {
+			ApplyTo []string `json:"applyTo"`
+		} `json:"credentials"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot load bundle: %s", err)
+	}
+	applyTo := make(map[string][]string, len(raw.Credentials))
+	for name, cred := range raw.Credentials {
+		applyTo[name] = cred.ApplyTo
+	}
+	return applyTo, nil
+}
+
+// appliesTo reports whether a credential that applies to the given actions applies to action. A credential without
+// actions applies to all actions.
+func appliesTo(actions []string, action string) bool {
+	if len(actions) == 0 {
+		return true
+	}
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
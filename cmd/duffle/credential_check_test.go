@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const checkTestBundle = `{
+  "name": "checked",
+  "version": "0.1.0",
+  "schemaVersion": "v1.0.0-WD",
+  "invocationImages": [{"imageType": "docker", "image": "checked:0.1.0"}],
+  "credentials": {
+    "kubeconfig": {"path": "/root/.kube/config", "required": true},
+    "token": {"env": "TOKEN", "required": true},
+    "password": {"env": "PASSWORD"},
+    "cleanup": {"env": "CLEANUP", "required": true, "applyTo": ["uninstall"]}
+  }
+}`
+
+func TestCredentialCheck(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	kubeconfig := filepath.Join(testHome.String(), "kubeconfig")
+	is.NoError(ioutil.WriteFile(bundleFile, []byte(checkTestBundle), 0644))
+	is.NoError(ioutil.WriteFile(kubeconfig, []byte("apiVersion: v1"), 0600))
+
+	first := "name: first\ncredentials:\n- name: token\n  source:\n    env: DUFFLE_CHECK_UNSET\n- name: kubeconfig\n  source:\n    path: /nonexistent/kubeconfig\n"
+	second := "name: second\ncredentials:\n- name: kubeconfig\n  source:\n    path: " + kubeconfig + "\n"
+	is.NoError(ioutil.WriteFile(filepath.Join(testHome.Credentials(), "first.yaml"), []byte(first), 0600))
+	is.NoError(ioutil.WriteFile(filepath.Join(testHome.Credentials(), "second.yaml"), []byte(second), 0600))
+
+	out := bytes.NewBuffer(nil)
+	check := &credentialCheckCmd{sets: []string{"first", "second"}, bundleFile: bundleFile, action: "install", home: testHome, out: out}
+	err := check.run()
+	is.EqualError(err, "1 of 4 credentials of checked are not satisfied for install")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	is.Len(lines, 5)
+	is.Regexp(`^cleanup\s+true\s+skipped: does not apply to install`, lines[1])
+	is.Regexp(`^kubeconfig\s+true\s+second\s+path \S+\s+ok`, lines[2], "the last credential set wins")
+	is.Regexp(`^password\s+false\s+missing`, lines[3], "optional credentials may be missing")
+	is.Regexp(`^token\s+true\s+first\s+env DUFFLE_CHECK_UNSET\s+unresolved: DUFFLE_CHECK_UNSET is not set`, lines[4])
+
+	os.Setenv("DUFFLE_CHECK_UNSET", "set")
+	defer os.Unsetenv("DUFFLE_CHECK_UNSET")
+	out.Reset()
+	is.NoError(check.run())
+
+	check.action = "uninstall"
+	out.Reset()
+	is.EqualError(check.run(), "1 of 4 credentials of checked are not satisfied for uninstall")
+	is.Regexp(`cleanup\s+true\s+missing`, out.String())
+
+	check.output = formatJSON
+	out.Reset()
+	is.Error(check.run(), "the command fails whatever the output format")
+	var checks []map[string]interface{}
+	is.NoError(json.Unmarshal(out.Bytes(), &checks))
+	is.Len(checks, 4)
+	is.Equal(map[string]interface{}{"name": "cleanup", "required": true, "status": "missing"}, checks[0])
+}
@@ -6,7 +6,9 @@ import (
 	"io/ioutil"
 	"path/filepath"
 
+	"github.com/cnabio/duffle/pkg/crypto/secret"
 	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/osutil"
 
 	"github.com/cnabio/cnab-go/credentials"
 	"github.com/ghodss/yaml"
@@ -17,7 +19,8 @@ import (
 const credentialEditDesc = `
 Open an editor for editing the named credential.
 
-Upon saving and exiting the editor, this will write the updated credential.
+Upon saving and exiting the editor, this will write the updated credential. An encrypted
+credential set is decrypted for editing, and encrypted again with the same key when it is saved.
 
 This uses the values of $EDITOR or $VISUAL to figure out which editor to use. If none is found,
 this will default to 'vi' on UNIX-like systems and Notepad on Windows.
@@ -48,12 +51,32 @@ func newCredentialEditCmd(w io.Writer) *cobra.Command {
 }
 
 func (c *credentialEditCmd) run() error {
-	creds, err := findCredentialSet(c.home.Credentials(), c.name)
+	path := filepath.Join(c.home.Credentials(), c.name+".yaml")
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
-	data, err := yaml.Marshal(creds)
+	// An encrypted credential set is decrypted into the editor, and encrypted again with the same key on save.
+	env, encrypted, err := parseEncryptedCredentialSet(data)
+	if err != nil {
+		return err
+	}
+	var key *secret.Key
+	if encrypted {
+		if key, err = credentialSetKey(c.home, env.Name, env.Encryption, false); err != nil {
+			return fmt.Errorf("cannot decrypt credential set %q: %v", env.Name, err)
+		}
+		if data, err = openCredentialSet(key, env); err != nil {
+			return err
+		}
+	}
+	creds := &credentials.CredentialSet{}
+	if err := yaml.Unmarshal(data, creds); err != nil {
+		return err
+	}
+
+	data, err = yaml.Marshal(creds)
 	if err != nil {
 		return err
 	}
@@ -86,6 +109,11 @@ func (c *credentialEditCmd) run() error {
 		return fmt.Errorf("new credentials are malformed: %s", err)
 	}
 
-	destpath := filepath.Join(c.home.Credentials(), c.name+".yaml")
-	return ioutil.WriteFile(destpath, []byte(dest), 0600)
+	out := []byte(dest)
+	if encrypted {
+		if out, err = sealCredentialSet(key, newcreds.Name, env.Encryption, out); err != nil {
+			return err
+		}
+	}
+	return osutil.AtomicWriteFile(path, out, 0600)
 }
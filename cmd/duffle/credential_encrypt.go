@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+
+	"github.com/cnabio/duffle/pkg/crypto/secret"
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/osutil"
+)
+
+const credentialEncryptDesc = `
+Encrypt a credential set in the local storage.
+
+The credential set is encrypted with NaCl secretbox. By default the key is kept in
+the Duffle home (encryption.key), and is generated if it does not exist yet. With
+'--passphrase', the key is derived from a passphrase instead, which is prompted for,
+or read from the environment variable DUFFLE_CREDENTIALS_PASSPHRASE.
+
+Encrypted credential sets are decrypted whenever they are used, for example by
+'duffle install --credentials NAME' or 'duffle credentials show NAME'.
+Use 'duffle credentials decrypt NAME' to store the credential set in plain text again.
+`
+
+// passphraseEnvVar holds the passphrase of encrypted credential sets, so that they can be used without a prompt.
+const passphraseEnvVar = "DUFFLE_CREDENTIALS_PASSPHRASE"
+
+// How the key of an encrypted credential set is obtained.
+const (
+	credentialKeyFile       = "file"
+	credentialKeyPassphrase = "passphrase"
+)
+
+// encryptedCredentialSet is the file format of an encrypted credential set. The name is kept in plain text, so that
+// the credential set can be listed without decrypting it.
+type encryptedCredentialSet struct {
+	Name       string               `json:"name"`
+	Encryption credentialEncryption `json:"encryption"`
+	// Data is the sealed YAML of the credential set.
+	Data string `json:"data"`
+}
+
+type credentialEncryption struct {
+	// Key is either credentialKeyFile or credentialKeyPassphrase.
+	Key string `json:"key"`
+	// Salt is the base64 encoded salt the key is derived from the passphrase with.
+	Salt string `json:"salt,omitempty"`
+}
+
+// readPassphrase returns the passphrase of encrypted credential sets. If confirm is set, a new passphrase is being
+// chosen, and is asked for twice.
+var readPassphrase = func(confirm bool) (string, error) {
+	if p, ok := os.LookupEnv(passphraseEnvVar); ok {
+		return p, nil
+	}
+
+	var passphrase string
+	if err := survey.AskOne(&survey.Password{Message: "Passphrase for the credential set:"}, &passphrase, survey.Required); err != nil {
+		return "", err
+	}
+	if !confirm {
+		return passphrase, nil
+	}
+	var again string
+	if err := survey.AskOne(&survey.Password{Message: "Repeat the passphrase:"}, &again, nil); err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", errors.New("the passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+type credentialEncryptCmd struct {
+	name       string
+	home       home.Home
+	out        io.Writer
+	passphrase bool
+}
+
+func newCredentialEncryptCmd(w io.Writer) *cobra.Command {
+	enc := &credentialEncryptCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "encrypt NAME",
+		Short: "encrypt a credential set",
+		Long:  credentialEncryptDesc,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enc.home = home.Home(homePath())
+			enc.name = args[0]
+			return enc.run()
+		},
+	}
+	cmd.Flags().BoolVar(&enc.passphrase, "passphrase", false, "derive the key from a passphrase instead of using the key in the Duffle home")
+
+	return cmd
+}
+
+func (e *credentialEncryptCmd) run() error {
+	path := findCreds(e.home.Credentials(), e.name)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, ok, err := parseEncryptedCredentialSet(data); err != nil {
+		return err
+	} else if ok {
+		return fmt.Errorf("credential set %q is already encrypted", e.name)
+	}
+	cs := &credentials.CredentialSet{}
+	if err := yaml.Unmarshal(data, cs); err != nil {
+		return fmt.Errorf("credential set %q is malformed: %v", e.name, err)
+	}
+
+	enc := credentialEncryption{Key: credentialKeyFile}
+	if e.passphrase {
+		salt, err := secret.NewSalt()
+		if err != nil {
+			return err
+		}
+		enc = credentialEncryption{Key: credentialKeyPassphrase, Salt: base64.StdEncoding.EncodeToString(salt)}
+	}
+	key, err := credentialSetKey(e.home, cs.Name, enc, true)
+	if err != nil {
+		return err
+	}
+	sealed, err := sealCredentialSet(key, cs.Name, enc, data)
+	if err != nil {
+		return err
+	}
+	if err := osutil.AtomicWriteFile(path, sealed, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "Encrypted credential set %s\n", cs.Name)
+	return nil
+}
+
+type credentialDecryptCmd struct {
+	name string
+	home home.Home
+	out  io.Writer
+}
+
+func newCredentialDecryptCmd(w io.Writer) *cobra.Command {
+	dec := &credentialDecryptCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "decrypt NAME",
+		Short: "store an encrypted credential set in plain text again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dec.home = home.Home(homePath())
+			dec.name = args[0]
+			return dec.run()
+		},
+	}
+
+	return cmd
+}
+
+func (d *credentialDecryptCmd) run() error {
+	path := findCreds(d.home.Credentials(), d.name)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	env, ok, err := parseEncryptedCredentialSet(data)
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("credential set %q is not encrypted", d.name)
+	}
+	plain, err := decryptCredentialSet(d.home, env)
+	if err != nil {
+		return err
+	}
+	if err := osutil.AtomicWriteFile(path, plain, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(d.out, "Decrypted credential set %s\n", env.Name)
+	return nil
+}
+
+// loadCredentialSet loads the credential set at path, decrypting it if it is encrypted.
+func loadCredentialSet(path string) (*credentials.CredentialSet, error) {
+	data, err := readCredentialSet(path)
+	if err != nil {
+		return nil, err
+	}
+	cs := &credentials.CredentialSet{}
+	return cs, yaml.Unmarshal(data, cs)
+}
+
+// readCredentialSet returns the YAML of the credential set at path, decrypting it if it is encrypted.
+func readCredentialSet(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	env, ok, err := parseEncryptedCredentialSet(data)
+	if err != nil || !ok {
+		return data, err
+	}
+	return decryptCredentialSet(home.Home(homePath()), env)
+}
+
+// parseEncryptedCredentialSet parses data as an encrypted credential set, and reports whether it is one.
+func parseEncryptedCredentialSet(data []byte) (*encryptedCredentialSet, bool, error) {
+	env := &encryptedCredentialSet{}
+	if err := yaml.Unmarshal(data, env); err != nil {
+		// not for us to judge; plain credential sets are validated when they are loaded
+		return nil, false, nil
+	}
+	if env.Encryption.Key == "" {
+		return nil, false, nil
+	}
+	if env.Encryption.Key != credentialKeyFile && env.Encryption.Key != credentialKeyPassphrase {
+		return nil, false, fmt.Errorf("credential set %q is encrypted with an unknown kind of key %q", env.Name, env.Encryption.Key)
+	}
+	return env, true, nil
+}
+
+// credentialSetKey returns the key the credential set is encrypted with. If confirm is set, a passphrase is being
+// chosen, and is asked for twice.
+func credentialSetKey(h home.Home, name string, enc credentialEncryption, confirm bool) (*secret.Key, error) {
+	if enc.Key == credentialKeyFile {
+		if confirm {
+			return secret.LoadOrCreateKey(h.EncryptionKey())
+		}
+		return secret.LoadKey(h.EncryptionKey())
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(enc.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("credential set %q has an invalid salt: %v", name, err)
+	}
+	passphrase, err := readPassphrase(confirm)
+	if err != nil {
+		return nil, err
+	}
+	return secret.KeyFromPassphrase(passphrase, salt)
+}
+
+// decryptCredentialSet returns the YAML of an encrypted credential set.
+func decryptCredentialSet(h home.Home, env *encryptedCredentialSet) ([]byte, error) {
+	key, err := credentialSetKey(h, env.Name, env.Encryption, false)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt credential set %q: %v", env.Name, err)
+	}
+	return openCredentialSet(key, env)
+}
+
+// openCredentialSet returns the YAML of an encrypted credential set, decrypted with key.
+func openCredentialSet(key *secret.Key, env *encryptedCredentialSet) ([]byte, error) {
+	plain, err := key.Open(env.Data)
+	if err == secret.ErrDecrypt && env.Encryption.Key == credentialKeyPassphrase {
+		return nil, fmt.Errorf("cannot decrypt credential set %q: wrong passphrase", env.Name)
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot decrypt credential set %q: %v", env.Name, err)
+	}
+	return plain, nil
+}
+
+// sealCredentialSet encrypts the YAML of a credential set with key, and returns the content of the encrypted file.
+func sealCredentialSet(key *secret.Key, name string, enc credentialEncryption, plain []byte) ([]byte, error) {
+	sealed, err := key.Seal(plain)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(encryptedCredentialSet{Name: name, Encryption: enc, Data: sealed})
+}
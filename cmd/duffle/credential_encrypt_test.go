@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+)
+
+func writeSecretCredentialSet(t *testing.T, h home.Home) string {
+	data, err := yaml.Marshal(credentials.CredentialSet{
+		Name: "secret",
+		Credentials: []credentials.CredentialStrategy{
+			{Name: "token", Source: credentials.Source{Value: "s3cr3t"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(h.Credentials(), "secret.yaml")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCredentialEncryptDecrypt(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+	path := writeSecretCredentialSet(t, testHome)
+	bun := &bundle.Bundle{Credentials: map[string]bundle.Credential{"token": {Location: bundle.Location{EnvironmentVariable: "TOKEN"}}}}
+
+	out := bytes.NewBuffer(nil)
+	is.NoError((&credentialEncryptCmd{name: "secret", home: testHome, out: out}).run())
+	is.Equal("Encrypted credential set secret\n", out.String())
+
+	data, err := ioutil.ReadFile(path)
+	is.NoError(err)
+	is.NotContains(string(data), "s3cr3t")
+	is.Contains(string(data), "name: secret")
+
+	err = (&credentialEncryptCmd{name: "secret", home: testHome, out: out}).run()
+	is.EqualError(err, `credential set "secret" is already encrypted`)
+
+	creds, err := loadCredentials([]string{"secret"}, bun)
+	is.NoError(err, "encrypted credential sets are decrypted transparently")
+	is.Equal("s3cr3t", creds["token"])
+
+	show := bytes.NewBuffer(nil)
+	is.NoError((&credentialShowCmd{name: "secret", home: testHome, out: show, unredacted: true}).run())
+	is.Contains(show.String(), "value: s3cr3t")
+
+	list := findCredentialSets(testHome.Credentials())
+	is.Len(list, 1)
+	is.Equal("secret", list[0].name)
+
+	is.NoError((&credentialDecryptCmd{name: "secret", home: testHome, out: ioutil.Discard}).run())
+	data, err = ioutil.ReadFile(path)
+	is.NoError(err)
+	is.Contains(string(data), "s3cr3t")
+
+	err = (&credentialDecryptCmd{name: "secret", home: testHome, out: ioutil.Discard}).run()
+	is.EqualError(err, `credential set "secret" is not encrypted`)
+}
+
+func TestCredentialEncryptPassphrase(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+	writeSecretCredentialSet(t, testHome)
+
+	defer func(read func(bool) (string, error)) { readPassphrase = read }(readPassphrase)
+	passphrase := "correct horse"
+	readPassphrase = func(bool) (string, error) { return passphrase, nil }
+
+	is.NoError((&credentialEncryptCmd{name: "secret", home: testHome, out: ioutil.Discard, passphrase: true}).run())
+	_, err := os.Stat(testHome.EncryptionKey())
+	is.True(os.IsNotExist(err), "no key file is needed with a passphrase")
+
+	cs, err := findCredentialSet(testHome.Credentials(), "secret")
+	is.NoError(err)
+	is.Equal("s3cr3t", cs.Credentials[0].Source.Value)
+
+	passphrase = "battery staple"
+	_, err = findCredentialSet(testHome.Credentials(), "secret")
+	is.EqualError(err, `cannot decrypt credential set "secret": wrong passphrase`)
+}
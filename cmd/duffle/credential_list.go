@@ -15,9 +15,16 @@ import (
 )
 
 type credentialListCmd struct {
-	out   io.Writer
-	home  home.Home
-	short bool
+	out    io.Writer
+	home   home.Home
+	short  bool
+	output outputFormat
+}
+
+// setItem is a credential or parameter set as printed by the list commands.
+type setItem struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
 }
 
 func newCredentialListCmd(w io.Writer) *cobra.Command {
@@ -36,11 +43,15 @@ func newCredentialListCmd(w io.Writer) *cobra.Command {
 
 	f := cmd.Flags()
 	f.BoolVarP(&list.short, "short", "s", false, "output shorter listing format")
+	list.output.addFlag(f)
 
 	return cmd
 }
 
 func (ls *credentialListCmd) run() error {
+	if err := ls.output.validateShort(ls.short); err != nil {
+		return err
+	}
 	credentialPath := ls.home.Credentials()
 	creds := findCredentialSets(credentialPath)
 
@@ -51,17 +62,23 @@ func (ls *credentialListCmd) run() error {
 		return nil
 	}
 
-	table := uitable.New()
-	table.MaxColWidth = 80
-	table.Wrap = true
-
-	table.AddRow("NAME", "PATH")
+	items := make([]setItem, 0, len(creds))
 	for _, cred := range creds {
-		table.AddRow(cred.name, cred.path)
+		items = append(items, setItem{Name: cred.name, Path: cred.path})
 	}
+	return ls.output.print(ls.out, items, func() error {
+		table := uitable.New()
+		table.MaxColWidth = 80
+		table.Wrap = true
+
+		table.AddRow("NAME", "PATH")
+		for _, item := range items {
+			table.AddRow(item.Name, item.Path)
+		}
 
-	fmt.Fprintln(ls.out, table)
-	return nil
+		fmt.Fprintln(ls.out, table)
+		return nil
+	})
 }
 
 type credListItem struct {
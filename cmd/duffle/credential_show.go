@@ -21,6 +21,7 @@ type credentialShowCmd struct {
 	home       home.Home
 	out        io.Writer
 	unredacted bool
+	output     outputFormat
 }
 
 func newCredentialShowCmd(w io.Writer) *cobra.Command {
@@ -37,10 +38,14 @@ func newCredentialShowCmd(w io.Writer) *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolVar(&show.unredacted, "unredacted", false, "Print the secret values without redacting them")
+	show.output.addShowFlag(cmd.Flags())
 	return cmd
 }
 
 func (sh *credentialShowCmd) run() error {
+	if err := sh.output.validate(); err != nil {
+		return err
+	}
 	cs, err := findCredentialSet(sh.home.Credentials(), sh.name)
 	if err != nil {
 		return err
@@ -61,20 +66,21 @@ func (sh *credentialShowCmd) printCredentials(cs credentials.CredentialSet) erro
 		cs.Credentials = creds
 	}
 
-	b, err := yaml.Marshal(cs.Name)
-	if err != nil {
-		return err
-	}
-	fmt.Fprintf(sh.out, "name: %s", string(b))
-	b, err = yaml.Marshal(cs.Credentials)
-	if err != nil {
-		return err
-	}
-	fmt.Fprintf(sh.out, "credentials:\n%s", string(b))
-
-	return nil
+	return sh.output.print(sh.out, cs, func() error {
+		b, err := yaml.Marshal(cs.Name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(sh.out, "name: %s", string(b))
+		b, err = yaml.Marshal(cs.Credentials)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(sh.out, "credentials:\n%s", string(b))
+		return nil
+	})
 }
 
 func findCredentialSet(dir, name string) (*credentials.CredentialSet, error) {
-	return credentials.Load(filepath.Join(dir, fmt.Sprintf("%s.yaml", name)))
+	return loadCredentialSet(filepath.Join(dir, fmt.Sprintf("%s.yaml", name)))
 }
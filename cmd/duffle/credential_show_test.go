@@ -32,6 +32,7 @@ func TestPrintCredentials(t *testing.T) {
 	testcases := []struct {
 		name       string
 		unredacted bool
+		format     outputFormat
 		output     string
 	}{
 		{name: "reacted", unredacted: false, output: `name: foo
@@ -62,6 +63,7 @@ credentials:
   source:
     env: MYSETTING
 `},
+		{name: "jsonpath", format: "jsonpath={.credentials[0].source.value}", output: "REDACTED\n"},
 	}
 
 	for _, tc := range testcases {
@@ -70,6 +72,7 @@ credentials:
 			show := &credentialShowCmd{
 				out:        output,
 				unredacted: tc.unredacted,
+				output:     tc.format,
 			}
 
 			err := show.printCredentials(*cs)
@@ -34,6 +34,22 @@ A credential set can retrieve actual credentials from the following four sources
 
 Note that in all of these cases, the local system (the system running Duffle) is used as
 the source of the credential.
+
+A credential can also be fetched from a secret store by a provider, without running a command:
+
+	credentials:
+	- name: db_password
+	  source:
+	    provider: vault
+	    key: secret/data/db#password
+
+The 'vault' provider reads a field of a secret from HashiCorp Vault, and is configured with the
+environment variables VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE. The 'http' provider fetches
+the secret KEY from $DUFFLE_SECRETS_URL/KEY, sending $DUFFLE_SECRETS_TOKEN as a bearer token if
+it is set.
+
+Credential sets holding hard-coded values can be encrypted at rest with
+'duffle credentials encrypt'. Encrypted credential sets are decrypted whenever they are used.
 `
 
 func newCredentialsCmd(w io.Writer) *cobra.Command {
@@ -51,6 +67,9 @@ func newCredentialsCmd(w io.Writer) *cobra.Command {
 		newCredentialShowCmd(w),
 		newCredentialGenerateCmd(w),
 		newCredentialEditCmd(w),
+		newCredentialEncryptCmd(w),
+		newCredentialDecryptCmd(w),
+		newCredentialCheckCmd(w),
 	)
 
 	return cmd
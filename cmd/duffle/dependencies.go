@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+
+	"github.com/cnabio/duffle/pkg/dependencies"
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+// resolveDependencies resolves the dependencies of bun to bundles in the local bundle store, failing if any of them
+// cannot be resolved.
+func resolveDependencies(h home.Home, bun *bundle.Bundle) ([]*dependencies.Node, error) {
+	if _, ok := bun.Custom[dependencies.ExtensionKey]; !ok {
+		return nil, nil
+	}
+	index, err := repo.LoadIndex(h.Repositories())
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %v", h.Repositories(), err)
+	}
+	nodes, err := dependencies.Resolve(bun, index, func(digest string) (*bundle.Bundle, error) {
+		return loadBundle(h.BundleFile(digest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, dependencies.Check(nodes)
+}
+
+// dependencyName returns the name of the installation of a dependency of the named installation.
+func dependencyName(installation, alias string) string {
+	return installation + "-" + alias
+}
+
+// installDependencies installs the dependencies of the installation, each as its own installation, and returns their
+// names. Dependencies that are already installed are left as they are.
+func (i *installCmd) installDependencies(nodes []*dependencies.Node) ([]string, error) {
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		name := dependencyName(i.name, n.Alias)
+		if _, err := claimStorage().Read(name); err == nil {
+			fmt.Fprintf(i.out, "Dependency %s is already installed as %s\n", n.Alias, name)
+			names = append(names, name)
+			continue
+		} else if err != claim.ErrClaimNotFound {
+			return names, err
+		}
+
+		fmt.Fprintf(i.out, "Installing dependency %s (%s:%s) as %s\n", n.Alias, n.Bundle.Name, n.Bundle.Version, name)
+		dep := &installCmd{
+			bundle:           i.home.BundleFile(n.Digest),
+			bundleIsFile:     true,
+			name:             name,
+			home:             i.home,
+			out:              i.out,
+			driver:           i.driver,
+			credentialsFiles: i.credentialsFiles,
+			control:          i.control,
+		}
+		err := dep.run()
+		if _, stored := claimStorage().Read(name); stored == nil {
+			names = append(names, name)
+		}
+		if err != nil {
+			return names, fmt.Errorf("could not install dependency %s: %v", n.Alias, err)
+		}
+	}
+	return names, nil
+}
+
+// printDependencyPlan prints the installations that would be created for the dependencies of an installation.
+func printDependencyPlan(w io.Writer, installation string, nodes []*dependencies.Node) {
+	for _, n := range nodes {
+		name := dependencyName(installation, n.Alias)
+		printDependencyPlan(w, name, n.Dependencies)
+		fmt.Fprintf(w, "Dependency %s (%s:%s) would be installed as %s\n", n.Alias, n.Bundle.Name, n.Bundle.Version, name)
+	}
+}
+
+// rememberDependencies records the installations of the dependencies of an installation in its claim.
+func rememberDependencies(c *claim.Claim, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	data := readClaimData(*c)
+	data.Dependencies = names
+	writeClaimData(c, data)
+}
+
+// uninstallDependencies uninstalls the installations of the dependencies of an installation, in the reverse of the
+// order they were installed in.
+func (un *uninstallCmd) uninstallDependencies(names []string) error {
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		if _, err := claimStorage().Read(name); err == claim.ErrClaimNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if un.dryRun {
+			fmt.Fprintf(un.out, "Dependency installation %s would be uninstalled\n", name)
+			continue
+		}
+		fmt.Fprintf(un.out, "Uninstalling dependency installation %s\n", name)
+		dep := &uninstallCmd{
+			name:      name,
+			out:       un.out,
+			driver:    un.driver,
+			control:   un.control,
+			force:     un.force,
+			keepClaim: un.keepClaim,
+		}
+		if err := dep.run(); err != nil {
+			return fmt.Errorf("could not uninstall dependency installation %s: %v", name, err)
+		}
+	}
+	return nil
+}
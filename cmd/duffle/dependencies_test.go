@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/dependencies"
+)
+
+func TestInstallAndUninstallDependencies(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	for _, version := range []string{"5.7.1", "5.7.3", "8.0.0"} {
+		db := dryRunTestBundle()
+		db.Name = "mysql"
+		db.Version = version
+		data, d, err := marshalBundle(db)
+		is.NoError(err)
+		is.NoError(storeBundle(testHome, d, data))
+		is.NoError(recordBundleReference(testHome, "mysql", version, d))
+	}
+
+	app := dryRunTestBundle()
+	app.Name = "app"
+	app.Custom = map[string]interface{}{
+		dependencies.ExtensionKey: map[string]interface{}{
+			"requires": map[string]interface{}{
+				"db": map[string]interface{}{"bundle": "mysql", "version": map[string]interface{}{"ranges": []string{"5.7.x"}}},
+			},
+		},
+	}
+	data, err := json.Marshal(app)
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "app.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	out := bytes.NewBuffer(nil)
+	is.NoError((&bundleDepsCmd{out: out, home: testHome, bundle: bundleFile, bundleIsFile: true}).run())
+	is.Equal("app:0.1.0\n  db: mysql:5.7.3 (5.7.x)\n", out.String())
+
+	out.Reset()
+	install := &installCmd{bundle: bundleFile, bundleIsFile: true, name: "shop", home: testHome, out: out, driver: "debug"}
+	is.NoError(install.run())
+	is.Contains(out.String(), "Installing dependency db (mysql:5.7.3) as shop-db")
+
+	dep, err := claimStorage().Read("shop-db")
+	is.NoError(err)
+	is.Equal("5.7.3", dep.Bundle.Version)
+	parent, err := claimStorage().Read("shop")
+	is.NoError(err)
+	is.Equal([]string{"shop-db"}, readClaimData(parent).Dependencies)
+
+	out.Reset()
+	is.NoError((&uninstallCmd{name: "shop", out: out, driver: "debug"}).run())
+	is.Contains(out.String(), "Uninstalling dependency installation shop-db")
+	for _, name := range []string{"shop", "shop-db"} {
+		_, err := claimStorage().Read(name)
+		is.Equal(claim.ErrClaimNotFound, err)
+	}
+
+	// a dependency that cannot be resolved fails the install before anything is installed
+	app.Custom[dependencies.ExtensionKey] = map[string]interface{}{
+		"requires": map[string]interface{}{"cache": map[string]interface{}{"bundle": "redis"}},
+	}
+	data, err = json.Marshal(app)
+	is.NoError(err)
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+	err = (&installCmd{bundle: bundleFile, bundleIsFile: true, name: "shop", home: testHome, out: ioutil.Discard, driver: "debug"}).run()
+	is.EqualError(err, "dependency cache: no version of redis in the local bundle store: no bundle name found")
+	_, err = claimStorage().Read("shop")
+	is.Equal(claim.ErrClaimNotFound, err)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/spf13/cobra"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+)
+
+const diffDesc = `Show what upgrading an installation would change.
+
+The installed claim is compared with the state it would have after running
+'duffle upgrade' with the same flags:
+
+- the bundle version
+- invocation images and images that were added, removed or changed
+- parameters that were added, removed or changed
+- credentials that the new bundle requires but the installed one did not
+
+Values of write-only parameters are not shown.
+
+Ex. $ duffle diff my-app --bundle my-app:0.2.0
+    $ duffle diff my-app --set replicas=3
+`
+
+type diffCmd struct {
+	out        io.Writer
+	name       string
+	bundle     string
+	bundleFile string
+	valuesFile string
+	setParams  []string
+	setFiles   []string
+}
+
+func newDiffCmd(w io.Writer) *cobra.Command {
+	diff := &diffCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "diff NAME",
+		Short: "show what upgrading an installation would change",
+		Long:  diffDesc,
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			bundleFile, err := prepareBundleFile(diff.bundle, diff.bundleFile)
+			diff.bundleFile = bundleFile
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			diff.name = args[0]
+			return diff.run()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&diff.bundle, "bundle", "b", "", "bundle to compare the installation with")
+	flags.StringVar(&diff.bundleFile, "bundle-file", "", "path of the bundle file to compare the installation with")
+	flags.StringVarP(&diff.valuesFile, "parameters", "p", "", "Specify a file containing parameters, or the name of a parameter set. File formats: json, yaml, toml")
+	flags.StringArrayVarP(&diff.setParams, "set", "s", []string{}, "Set individual parameters as NAME=VALUE pairs")
+	flags.StringArrayVarP(&diff.setFiles, "set-file", "i", []string{}, "Set individual parameters from file content as NAME=SOURCE-PATH pairs")
+
+	return cmd
+}
+
+func (d *diffCmd) run() error {
+	installed, err := claimStorage().Read(d.name)
+	if err != nil {
+		return fmt.Errorf("%v not found: %v", d.name, err)
+	}
+
+	up := &upgradeCmd{
+		name:       d.name,
+		bundleFile: d.bundleFile,
+		valuesFile: d.valuesFile,
+		setParams:  d.setParams,
+		setFiles:   d.setFiles,
+	}
+	proposed, err := up.proposedClaim(installed)
+	if err != nil {
+		return err
+	}
+
+	diffClaims(installed, proposed).print(d.out)
+	return nil
+}
+
+// upgradeDiff is the difference between an installed claim and the claim an upgrade would produce.
+type upgradeDiff struct {
+	oldVersion, newVersion string
+
+	invocationImages []valueChange
+	images           []valueChange
+	parameters       []valueChange
+
+	// newCredentials are the credentials that are required by the new bundle, but were not by the installed one.
+	newCredentials []string
+}
+
+// valueChange is a single added, removed or changed value. A missing old value means it was added, a missing new
+// value that it was removed.
+type valueChange struct {
+	name     string
+	old, new interface{}
+}
+
+func (c valueChange) String() string {
+	switch {
+	case c.old == nil:
+		return fmt.Sprintf("+ %s: %v", c.name, c.new)
+	case c.new == nil:
+		return fmt.Sprintf("- %s: %v", c.name, c.old)
+	default:
+		return fmt.Sprintf("~ %s: %v -> %v", c.name, c.old, c.new)
+	}
+}
+
+// diffClaims compares the installed claim with the proposed one.
+func diffClaims(installed, proposed claim.Claim) upgradeDiff {
+	oldBun, newBun := installed.Bundle, proposed.Bundle
+	if oldBun == nil {
+		oldBun = &bundle.Bundle{}
+	}
+	if newBun == nil {
+		newBun = &bundle.Bundle{}
+	}
+
+	d := upgradeDiff{
+		oldVersion: oldBun.Version,
+		newVersion: newBun.Version,
+	}
+
+	oldInvImages := map[string]interface{}{}
+	for i, img := range oldBun.InvocationImages {
+		oldInvImages[fmt.Sprintf("invocation image %d", i)] = imageRef(img.BaseImage)
+	}
+	newInvImages := map[string]interface{}{}
+	for i, img := range newBun.InvocationImages {
+		newInvImages[fmt.Sprintf("invocation image %d", i)] = imageRef(img.BaseImage)
+	}
+	d.invocationImages = diffValues(oldInvImages, newInvImages)
+
+	oldImages := map[string]interface{}{}
+	for name, img := range oldBun.Images {
+		oldImages[name] = imageRef(img.BaseImage)
+	}
+	newImages := map[string]interface{}{}
+	for name, img := range newBun.Images {
+		newImages[name] = imageRef(img.BaseImage)
+	}
+	d.images = diffValues(oldImages, newImages)
+
+	d.parameters = diffValues(installed.Parameters, proposed.Parameters)
+	maskWriteOnly(d.parameters, oldBun, newBun)
+
+	for name, cred := range newBun.Credentials {
+		if !cred.Required {
+			continue
+		}
+		if old, ok := oldBun.Credentials[name]; ok && old.Required {
+			continue
+		}
+		d.newCredentials = append(d.newCredentials, name)
+	}
+	sort.Strings(d.newCredentials)
+
+	return d
+}
+
+// empty reports whether the upgrade would change nothing that is compared.
+func (d upgradeDiff) empty() bool {
+	return d.oldVersion == d.newVersion && len(d.invocationImages) == 0 && len(d.images) == 0 &&
+		len(d.parameters) == 0 && len(d.newCredentials) == 0
+}
+
+func (d upgradeDiff) print(w io.Writer) {
+	if d.empty() {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+	if d.oldVersion != d.newVersion {
+		fmt.Fprintf(w, "Bundle version: %s -> %s\n", d.oldVersion, d.newVersion)
+	}
+	printChanges := func(title string, changes []valueChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "%s:\n", title)
+		for _, c := range changes {
+			fmt.Fprintf(w, "  %s\n", c)
+		}
+	}
+	printChanges("Invocation images", d.invocationImages)
+	printChanges("Images", d.images)
+	printChanges("Parameters", d.parameters)
+	if len(d.newCredentials) > 0 {
+		fmt.Fprintln(w, "Newly required credentials:")
+		for _, name := range d.newCredentials {
+			fmt.Fprintf(w, "  + %s\n", name)
+		}
+	}
+}
+
+// diffValues returns the values that were added, removed or changed between old and new, sorted by name.
+func diffValues(old, new map[string]interface{}) []valueChange {
+	changes := []valueChange{}
+	for name, o := range old {
+		n, ok := new[name]
+		if !ok {
+			changes = append(changes, valueChange{name: name, old: o})
+		} else if !sameValue(o, n) {
+			changes = append(changes, valueChange{name: name, old: o, new: n})
+		}
+	}
+	for name, n := range new {
+		if _, ok := old[name]; !ok {
+			changes = append(changes, valueChange{name: name, new: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].name < changes[j].name })
+	return changes
+}
+
+// sameValue compares values by their JSON encoding, since parameters read back from a stored claim are decoded
+// from JSON and have different types than freshly parsed ones.
+func sameValue(a, b interface{}) bool {
+	aj, aerr := json.Marshal(a)
+	bj, berr := json.Marshal(b)
+	if aerr != nil || berr != nil {
+		return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+	}
+	return bytes.Equal(aj, bj)
+}
+
+// maskWriteOnly masks the old and new values of the changes to write-only parameters. The definition is looked up in
+// the bundle the value belongs to.
+func maskWriteOnly(changes []valueChange, oldBun, newBun *bundle.Bundle) {
+	writeOnly := func(bun *bundle.Bundle, name string) bool {
+		param, ok := bun.Parameters[name]
+		if !ok {
+			return false
+		}
+		schema, ok := bun.Definitions[param.Definition]
+		return ok && isWriteOnly(schema)
+	}
+	for i, c := range changes {
+		if c.old != nil && writeOnly(oldBun, c.name) {
+			changes[i].old = maskedValue
+		}
+		if c.new != nil && writeOnly(newBun, c.name) {
+			changes[i].new = maskedValue
+		}
+	}
+}
+
+// imageRef describes an image by its reference and, if known, its digest.
+func imageRef(img bundle.BaseImage) string {
+	if img.Digest == "" {
+		return img.Image
+	}
+	return img.Image + "@" + img.Digest
+}
+
+// surveyConfirm asks the user a yes/no question on the terminal. The answer defaults to no.
+func surveyConfirm(message string) (bool, error) {
+	var answer bool
+	err := survey.AskOne(&survey.Confirm{Message: message}, &answer, nil)
+	return answer, err
+}
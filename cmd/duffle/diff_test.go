@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/stretchr/testify/assert"
+)
+
+func diffTestBundle(version string) *bundle.Bundle {
+	writeOnly := true
+	return &bundle.Bundle{
+		SchemaVersion: "v1.0.0-WD",
+		Name:          "diff",
+		Version:       version,
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "diff/cnab:" + version, ImageType: "docker"}},
+		},
+		Images: map[string]bundle.Image{
+			"web": {BaseImage: bundle.BaseImage{Image: "diff/web:" + version, ImageType: "docker"}},
+		},
+		Definitions: definition.Definitions{
+			"string":   {Type: "string"},
+			"password": {Type: "string", WriteOnly: &writeOnly},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"host":     {Definition: "string"},
+			"password": {Definition: "password"},
+		},
+		Credentials: map[string]bundle.Credential{
+			"kubeconfig": {Location: bundle.Location{Path: "/root/.kube/config"}, Required: true},
+		},
+	}
+}
+
+func TestDiffClaims(t *testing.T) {
+	is := assert.New(t)
+
+	installed := claim.Claim{
+		Bundle:     diffTestBundle("0.1.0"),
+		Parameters: map[string]interface{}{"host": "example.com", "password": "old", "replicas": float64(2)},
+	}
+	proposed := installed
+	proposed.Bundle = diffTestBundle("0.2.0")
+	proposed.Bundle.Parameters["port"] = bundle.Parameter{Definition: "string"}
+	proposed.Bundle.Credentials["token"] = bundle.Credential{Location: bundle.Location{EnvironmentVariable: "TOKEN"}, Required: true}
+	proposed.Parameters = map[string]interface{}{"host": "example.com", "password": "new", "port": "8080"}
+
+	d := diffClaims(installed, proposed)
+	is.False(d.empty())
+	is.Equal("0.1.0", d.oldVersion)
+	is.Equal("0.2.0", d.newVersion)
+	is.Equal([]valueChange{{name: "invocation image 0", old: "diff/cnab:0.1.0", new: "diff/cnab:0.2.0"}}, d.invocationImages)
+	is.Equal([]valueChange{{name: "web", old: "diff/web:0.1.0", new: "diff/web:0.2.0"}}, d.images)
+	is.Equal([]valueChange{
+		{name: "password", old: maskedValue, new: maskedValue},
+		{name: "port", new: "8080"},
+		{name: "replicas", old: float64(2)},
+	}, d.parameters)
+	is.Equal([]string{"token"}, d.newCredentials)
+
+	out := bytes.NewBuffer(nil)
+	d.print(out)
+	is.Contains(out.String(), "Bundle version: 0.1.0 -> 0.2.0")
+	is.Contains(out.String(), "~ password: ***** -> *****")
+	is.Contains(out.String(), "+ port: 8080")
+	is.Contains(out.String(), "- replicas: 2")
+	is.Contains(out.String(), "+ token")
+	is.NotContains(out.String(), "-> new", "write-only values must not be shown")
+
+	same := installed
+	same.Parameters = map[string]interface{}{"host": "example.com", "password": "old", "replicas": 2}
+	is.True(diffClaims(installed, same).empty(), "values are compared regardless of their Go type")
+}
+
+func TestUpgradeDiff(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	c, err := claim.New("diffed")
+	is.NoError(err)
+	c.Bundle = diffTestBundle("0.1.0")
+	c.Bundle.Credentials = nil
+	c.Parameters = map[string]interface{}{"host": "example.com"}
+	is.NoError(claimStorage().Store(*c))
+
+	newBun := diffTestBundle("0.1.0")
+	newBun.Credentials = nil
+	newBun.InvocationImages[0].Image = "diff/cnab:0.1.1"
+	data, err := json.Marshal(newBun)
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	out := bytes.NewBuffer(nil)
+	asked := 0
+	up := &upgradeCmd{
+		name:       "diffed",
+		out:        out,
+		driver:     "debug",
+		bundleFile: bundleFile,
+		setParams:  []string{"host=example.org"},
+		diff:       true,
+		confirm: func(string) (bool, error) {
+			asked++
+			return false, nil
+		},
+	}
+	is.NoError(up.run())
+	is.Equal(1, asked)
+	is.Contains(out.String(), "~ invocation image 0: diff/cnab:0.1.0 -> diff/cnab:0.1.1")
+	is.Contains(out.String(), "~ host: example.com -> example.org")
+	is.Contains(out.String(), "Upgrade cancelled.")
+
+	stored, err := claimStorage().Read("diffed")
+	is.NoError(err)
+	is.Equal("example.com", stored.Parameters["host"], "a declined upgrade must not change the claim")
+
+	up.yes = true
+	is.NoError(up.run())
+	is.Equal(1, asked, "--yes skips the confirmation")
+
+	stored, err = claimStorage().Read("diffed")
+	is.NoError(err)
+	is.Equal("example.org", stored.Parameters["host"])
+	is.Equal(claim.ActionUpgrade, stored.Result.Action)
+}
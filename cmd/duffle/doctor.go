@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/claimstore"
+	"github.com/cnabio/duffle/pkg/crypto/digest"
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/filestore"
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+const doctorDesc = `Check the integrity of the local Duffle storage.
+
+This re-hashes every bundle in the local storage and reports:
+
+- bundles whose content does not match the digest they are stored under
+- bundle names and versions that refer to a missing bundle file
+- claims that cannot be read
+- credential sets that cannot be read
+
+If '--fix' is set, the problems are repaired: bundles are re-keyed under their actual
+digest, dangling bundle names and versions are removed, and unreadable bundles, claims
+and credential sets are renamed with a '.broken' extension so they no longer get in the way.
+`
+
+// brokenExt is appended to the name of files that are moved out of the way by 'duffle doctor --fix'.
+const brokenExt = ".broken"
+
+type doctorCmd struct {
+	home home.Home
+	out  io.Writer
+	fix  bool
+}
+
+// storeProblem is an integrity problem found in the local storage.
+type storeProblem struct {
+	// description of the problem
+	desc string
+	// fixDesc describes how fix repairs the problem
+	fixDesc string
+	// fix repairs the problem, or is nil if it cannot be repaired automatically
+	fix func() error
+}
+
+func newDoctorCmd(w io.Writer) *cobra.Command {
+	doctor := &doctorCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "check the integrity of the local storage",
+		Long:  doctorDesc,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			doctor.home = home.Home(homePath())
+			return doctor.run()
+		},
+	}
+	cmd.Flags().BoolVar(&doctor.fix, "fix", false, "repair the problems that were found")
+
+	return cmd
+}
+
+func (d *doctorCmd) run() error {
+	lock, err := repo.LockIndex(d.home.Repositories())
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	index, err := repo.LoadIndex(d.home.Repositories())
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %v", d.home.Repositories(), err)
+	}
+
+	problems, err := d.checkBundles(index)
+	if err != nil {
+		return err
+	}
+	claimProblems, err := d.checkClaims()
+	if err != nil {
+		return err
+	}
+	credProblems, err := d.checkCredentialSets()
+	if err != nil {
+		return err
+	}
+	problems = append(problems, claimProblems...)
+	problems = append(problems, credProblems...)
+
+	if len(problems) == 0 {
+		fmt.Fprintln(d.out, "No problems found.")
+		return nil
+	}
+
+	unfixed := 0
+	for _, p := range problems {
+		fmt.Fprintf(d.out, "- %s\n", p.desc)
+		if !d.fix || p.fix == nil {
+			unfixed++
+			continue
+		}
+		if err := p.fix(); err != nil {
+			fmt.Fprintf(d.out, "  could not fix: %v\n", err)
+			unfixed++
+			continue
+		}
+		fmt.Fprintf(d.out, "  fixed: %s\n", p.fixDesc)
+	}
+
+	if d.fix {
+		if err := index.WriteFile(d.home.Repositories(), 0644); err != nil {
+			return fmt.Errorf("could not write to %s: %v", d.home.Repositories(), err)
+		}
+	}
+
+	if unfixed == 0 {
+		fmt.Fprintf(d.out, "Fixed %d problem(s).\n", len(problems))
+		return nil
+	}
+	if !d.fix {
+		return fmt.Errorf("found %d problem(s); run 'duffle doctor --fix' to repair them", unfixed)
+	}
+	return fmt.Errorf("%d problem(s) could not be fixed", unfixed)
+}
+
+// checkBundles re-hashes the bundles referenced by the index and reports dangling or mismatched entries.
+//
+// Fixes modify index in place; it is up to the caller to write it back.
+func (d *doctorCmd) checkBundles(index repo.Index) ([]storeProblem, error) {
+	// group the index entries by digest, so that each bundle file is only checked once
+	refs := map[string][]string{}
+	for name, versions := range index {
+		for version, dgst := range versions {
+			refs[dgst] = append(refs[dgst], name+":"+version)
+		}
+	}
+	digests := make([]string, 0, len(refs))
+	for dgst := range refs {
+		digests = append(digests, dgst)
+		sort.Strings(refs[dgst])
+	}
+	sort.Strings(digests)
+
+	deleteEntries := func(dgst string) {
+		for name, versions := range index {
+			for version, v := range versions {
+				if v == dgst {
+					index.DeleteVersion(name, version)
+				}
+			}
+			if len(index[name]) == 0 {
+				index.Delete(name)
+			}
+		}
+	}
+
+	problems := []storeProblem{}
+	for _, dgst := range digests {
+		dgst := dgst
+		names := strings.Join(refs[dgst], ", ")
+		path := d.home.BundleFile(dgst)
+
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			problems = append(problems, storeProblem{
+				desc:    fmt.Sprintf("%s refer(s) to missing bundle %s", names, dgst),
+				fixDesc: fmt.Sprintf("removed %s from the index", names),
+				fix: func() error {
+					deleteEntries(dgst)
+					return nil
+				},
+			})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		actual := digest.OfBuffer(data).String()
+		if digest.Matches(actual, dgst) {
+			continue
+		}
+
+		if _, err := bundle.Unmarshal(data); err != nil {
+			problems = append(problems, storeProblem{
+				desc:    fmt.Sprintf("bundle %s for %s is corrupt: %v", dgst, names, err),
+				fixDesc: fmt.Sprintf("moved it to %s and removed %s from the index", path+brokenExt, names),
+				fix: func() error {
+					if err := os.Rename(path, path+brokenExt); err != nil {
+						return err
+					}
+					deleteEntries(dgst)
+					return nil
+				},
+			})
+			continue
+		}
+
+		problems = append(problems, storeProblem{
+			desc:    fmt.Sprintf("bundle %s for %s does not match its digest (actual digest %s)", dgst, names, actual),
+			fixDesc: fmt.Sprintf("stored it as %s", actual),
+			fix: func() error {
+				if err := storeBundle(d.home, actual, data); err != nil {
+					return err
+				}
+				index.Rekey(dgst, actual)
+				return os.Remove(path)
+			},
+		})
+	}
+	return problems, nil
+}
+
+// checkClaims reports the claims that cannot be read.
+func (d *doctorCmd) checkClaims() ([]storeProblem, error) {
+	store := claim.NewClaimStore(claimstore.New(filestore.New(d.home.Claims(), "json"), d.home.EncryptionKey()))
+	names, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	problems := []storeProblem{}
+	for _, name := range names {
+		if _, err := store.Read(name); err != nil {
+			problems = append(problems, d.brokenFile(fmt.Sprintf("claim %q", name), filepath.Join(d.home.Claims(), name+".json"), err))
+		}
+	}
+	return problems, nil
+}
+
+// checkCredentialSets reports the credential sets that cannot be read.
+func (d *doctorCmd) checkCredentialSets() ([]storeProblem, error) {
+	problems := []storeProblem{}
+	err := filepath.Walk(d.home.Credentials(), func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || filepath.Ext(path) == brokenExt {
+			return nil
+		}
+		if _, err := credentials.Load(path); err != nil {
+			problems = append(problems, d.brokenFile("credential set "+path, path, err))
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return problems, nil
+}
+
+// brokenFile reports an unreadable file, which is fixed by moving it out of the way.
+func (d *doctorCmd) brokenFile(what, path string, err error) storeProblem {
+	return storeProblem{
+		desc:    fmt.Sprintf("%s cannot be read: %v", what, err),
+		fixDesc: fmt.Sprintf("moved it to %s", path+brokenExt),
+		fix: func() error {
+			return os.Rename(path, path+brokenExt)
+		},
+	}
+}
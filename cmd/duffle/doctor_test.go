@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+func TestDoctor(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	_, healthy := storeTestBundle(t, "foo", "1.0.0")
+	is.NoError(recordBundleReference(testHome, "foo", "1.0.0", healthy))
+
+	// an index entry without a bundle file
+	dangling := "sha256:" + string(bytes.Repeat([]byte("a"), 64))
+	is.NoError(recordBundleReference(testHome, "foo", "1.1.0", dangling))
+
+	// a valid bundle stored under the wrong digest
+	_, actual := storeTestBundle(t, "bar", "1.0.0")
+	mismatched := "sha256:" + string(bytes.Repeat([]byte("b"), 64))
+	is.NoError(os.Rename(testHome.BundleFile(actual), testHome.BundleFile(mismatched)))
+	is.NoError(recordBundleReference(testHome, "bar", "1.0.0", mismatched))
+
+	// a bundle file that is not a bundle at all
+	corrupt := "sha256:" + string(bytes.Repeat([]byte("c"), 64))
+	is.NoError(storeBundle(testHome, corrupt, []byte("not a bundle")))
+	is.NoError(recordBundleReference(testHome, "baz", "1.0.0", corrupt))
+
+	brokenClaim := filepath.Join(testHome.Claims(), "broken.json")
+	is.NoError(ioutil.WriteFile(brokenClaim, []byte("{"), 0644))
+	brokenCreds := filepath.Join(testHome.Credentials(), "broken.yaml")
+	is.NoError(ioutil.WriteFile(brokenCreds, []byte("credentials: [\n"), 0644))
+
+	out := bytes.NewBuffer(nil)
+	cmd := &doctorCmd{home: testHome, out: out}
+	err := cmd.run()
+	is.EqualError(err, "found 5 problem(s); run 'duffle doctor --fix' to repair them")
+	is.Contains(out.String(), "foo:1.1.0 refer(s) to missing bundle "+dangling)
+	is.Contains(out.String(), "bundle "+mismatched+" for bar:1.0.0 does not match its digest (actual digest "+actual+")")
+	is.Contains(out.String(), "bundle "+corrupt+" for baz:1.0.0 is corrupt")
+	is.Contains(out.String(), `claim "broken" cannot be read`)
+	is.Contains(out.String(), "credential set "+brokenCreds+" cannot be read")
+
+	out.Reset()
+	cmd.fix = true
+	is.NoError(cmd.run())
+	is.Contains(out.String(), "Fixed 5 problem(s).")
+
+	index, err := repo.LoadIndex(testHome.Repositories())
+	is.NoError(err)
+	is.Equal(repo.Index{
+		"foo": {"1.0.0": healthy},
+		"bar": {"1.0.0": actual},
+	}, index)
+	is.FileExists(testHome.BundleFile(actual))
+	is.False(fileExists(testHome.BundleFile(mismatched)))
+	is.FileExists(testHome.BundleFile(corrupt) + ".broken")
+	is.FileExists(brokenClaim + ".broken")
+	is.FileExists(brokenCreds + ".broken")
+
+	out.Reset()
+	cmd.fix = false
+	is.NoError(cmd.run())
+	is.Equal("No problems found.\n", out.String())
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/cnabio/cnab-go/driver"
+
+	"github.com/cnabio/duffle/pkg/redact"
+)
+
+// maskedValue replaces the values of credentials and write-only parameters in dry-run output.
+const maskedValue = redact.Mask
+
+// What would happen to the claim of an installation after an action.
+const (
+	claimStore  = "store"
+	claimDelete = "delete"
+	claimKeep   = "none"
+)
+
+// dryRunDriver stands in for the driver of an action run with --dry-run. It records the operation that would have
+// been run instead of running it.
+//
+// The wrapped driver still decides which invocation images are supported.
+type dryRunDriver struct {
+	driver.Driver
+
+	op *driver.Operation
+}
+
+// Run records the operation without running it.
+func (d *dryRunDriver) Run(op *driver.Operation) (driver.OperationResult, error) {
+	d.op = op
+	return driver.OperationResult{}, nil
+}
+
+// dryRunPlan is what an action would do.
+type dryRunPlan struct {
+	// Operation is the fully resolved operation that would be sent to the driver.
+	Operation dryRunOperation `json:"operation"`
+	// ClaimAction is what would happen to the claim: "store", "delete" or "none".
+	ClaimAction string `json:"claimAction"`
+	// Claim is the claim that would be stored, if any.
+	Claim *claim.Claim `json:"claim,omitempty"`
+}
+
+type dryRunOperation struct {
+	Installation string                 `json:"installation"`
+	Revision     string                 `json:"revision"`
+	Action       string                 `json:"action"`
+	Image        bundle.InvocationImage `json:"image"`
+	Parameters   map[string]dryRunValue `json:"parameters"`
+	Credentials  map[string]dryRunValue `json:"credentials"`
+	Environment  map[string]string      `json:"environment"`
+	Files        map[string]string      `json:"files"`
+	Outputs      []string               `json:"outputs"`
+}
+
+// dryRunValue is a parameter or credential value and where it is injected into the invocation image.
+type dryRunValue struct {
+	Value interface{} `json:"value"`
+	Env   string      `json:"env,omitempty"`
+	Path  string      `json:"path,omitempty"`
+}
+
+// printPlan writes the recorded operation, with secret values masked, and the fate of the claim to w.
+//
+// If the claim would be stored, its result is marked as unknown, since the action was not performed.
+func (d *dryRunDriver) printPlan(w io.Writer, c *claim.Claim, creds credentials.Set, claimAction string) error {
+	if d.op == nil {
+		return errors.New("no operation was sent to the driver")
+	}
+
+	plan := dryRunPlan{
+		Operation:   maskOperation(d.op, creds),
+		ClaimAction: claimAction,
+	}
+	if claimAction == claimStore {
+		c.Result.Status = claim.StatusUnknown
+		c.Result.Message = "dry run: the operation was not executed"
+		masked := maskClaim(*c)
+		plan.Claim = &masked
+	}
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
+
+// maskOperation describes op with the values of credentials and write-only parameters masked wherever they are
+// injected.
+func maskOperation(op *driver.Operation, creds credentials.Set) dryRunOperation {
+	secrets := operationSecrets(op)
+	out := dryRunOperation{
+		Installation: op.Installation,
+		Revision:     op.Revision,
+		Action:       op.Action,
+		Image:        op.Image,
+		Parameters:   map[string]dryRunValue{},
+		Credentials:  map[string]dryRunValue{},
+		Environment:  map[string]string{},
+		Files:        map[string]string{},
+		Outputs:      op.Outputs,
+	}
+	for k, v := range op.Environment {
+		out.Environment[k] = redact.String(v, secrets)
+	}
+	for k, v := range op.Files {
+		out.Files[k] = redact.String(v, secrets)
+	}
+
+	for name, param := range op.Bundle.Parameters {
+		val, ok := op.Parameters[name]
+		if !ok {
+			continue
+		}
+		if isWriteOnlyDefinition(op.Bundle, param.Definition) {
+			val = maskedValue
+		}
+		dest := dryRunValue{Value: val}
+		if param.Destination == nil {
+			dest.Env = fmt.Sprintf("CNAB_P_%s", strings.ToUpper(name))
+		} else {
+			dest.Env = param.Destination.EnvironmentVariable
+			dest.Path = param.Destination.Path
+		}
+		out.Parameters[name] = dest
+	}
+
+	for name, cred := range op.Bundle.Credentials {
+		if _, ok := creds[name]; !ok {
+			continue
+		}
+		out.Credentials[name] = dryRunValue{
+			Value: maskedValue,
+			Env:   cred.EnvironmentVariable,
+			Path:  cred.Path,
+		}
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func dryRunTestBundle() *bundle.Bundle {
+	return &bundle.Bundle{
+		SchemaVersion: "v1.0.0-WD",
+		Name:          "dryrun",
+		Version:       "0.1.0",
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "dryrun/cnab:0.1.0", ImageType: "docker"}},
+		},
+		Definitions: definition.Definitions{
+			"port": {Type: "integer", Default: 8080},
+			"host": {Type: "string", Default: "example.com"},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"port": {Definition: "port"},
+			"host": {Definition: "host", Destination: &bundle.Location{Path: "/cnab/app/host"}},
+		},
+		Credentials: map[string]bundle.Credential{
+			"token": {Location: bundle.Location{EnvironmentVariable: "TOKEN", Path: "/cnab/app/token"}},
+		},
+	}
+}
+
+func TestInstallDryRun(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	data, err := json.Marshal(dryRunTestBundle())
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	creds := credentials.CredentialSet{
+		Name: "secret",
+		Credentials: []credentials.CredentialStrategy{
+			{Name: "token", Source: credentials.Source{Value: "s3cr3t"}},
+		},
+	}
+	data, err = yaml.Marshal(creds)
+	is.NoError(err)
+	credFile := filepath.Join(testHome.String(), "secret.yaml")
+	is.NoError(ioutil.WriteFile(credFile, data, 0644))
+
+	out := bytes.NewBuffer(nil)
+	install := &installCmd{
+		bundle:           bundleFile,
+		bundleIsFile:     true,
+		name:             "planned",
+		home:             testHome,
+		out:              out,
+		driver:           "debug",
+		credentialsFiles: []string{credFile},
+		dryRun:           true,
+	}
+	is.NoError(install.run())
+	is.NotContains(out.String(), "s3cr3t", "credential values must be masked")
+
+	var plan dryRunPlan
+	is.NoError(json.Unmarshal(out.Bytes(), &plan))
+	is.Equal("install", plan.Operation.Action)
+	is.Equal("dryrun/cnab:0.1.0", plan.Operation.Image.Image)
+	is.Equal(maskedValue, plan.Operation.Environment["TOKEN"])
+	is.Equal(maskedValue, plan.Operation.Files["/cnab/app/token"])
+	is.Equal(dryRunValue{Value: maskedValue, Env: "TOKEN", Path: "/cnab/app/token"}, plan.Operation.Credentials["token"])
+	is.Equal(dryRunValue{Value: float64(8080), Env: "CNAB_P_PORT"}, plan.Operation.Parameters["port"])
+	is.Equal(dryRunValue{Value: "example.com", Path: "/cnab/app/host"}, plan.Operation.Parameters["host"])
+
+	is.Equal(claimStore, plan.ClaimAction)
+	if is.NotNil(plan.Claim) {
+		is.Equal("planned", plan.Claim.Name)
+		is.Equal(claim.StatusUnknown, plan.Claim.Result.Status)
+	}
+
+	_, err = claimStorage().Read("planned")
+	is.Equal(claim.ErrClaimNotFound, err, "a dry run must not store a claim")
+}
+
+func TestUninstallDryRun(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	c, err := claim.New("planned")
+	is.NoError(err)
+	c.Bundle = dryRunTestBundle()
+	c.Parameters = map[string]interface{}{"port": 80, "host": "example.org"}
+	is.NoError(claimStorage().Store(*c))
+
+	out := bytes.NewBuffer(nil)
+	uninstall := &uninstallCmd{
+		name:   "planned",
+		out:    out,
+		driver: "debug",
+		dryRun: true,
+	}
+	is.NoError(uninstall.run())
+	is.True(strings.HasPrefix(out.String(), "{"), "only the plan is printed")
+
+	var plan dryRunPlan
+	is.NoError(json.Unmarshal(out.Bytes(), &plan))
+	is.Equal("uninstall", plan.Operation.Action)
+	is.Equal(claimDelete, plan.ClaimAction)
+	is.Nil(plan.Claim)
+
+	_, err = claimStorage().Read("planned")
+	is.NoError(err, "a dry run must not delete the claim")
+}
@@ -3,13 +3,17 @@ package main
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 
+	godigest "github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
+	"github.com/cnabio/duffle/pkg/crypto/digest"
 	"github.com/cnabio/duffle/pkg/duffle/home"
 	"github.com/cnabio/duffle/pkg/ohai"
+	"github.com/cnabio/duffle/pkg/repo"
 )
 
 const (
@@ -21,6 +25,9 @@ Normally, Duffle initializes itself. But on occasion, you may wish to customize
 
 This command will create a subdirectory in your home directory, and use that directory for storing
 configuration, preferences, and persistent data.
+
+Bundles stored by earlier versions of Duffle under truncated digests are migrated to full
+SHA-256 digests. The truncated digests can still be used to refer to those bundles.
 `
 )
 
@@ -67,6 +74,7 @@ func (i *initCmd) run() error {
 		home.Plugins(),
 		home.Claims(),
 		home.Credentials(),
+		home.Parameters(),
 	}
 
 	files := []string{
@@ -95,9 +103,74 @@ func (i *initCmd) run() error {
 		}
 	}
 
+	if !i.dryRun {
+		migrated, err := migrateBundleDigests(home)
+		if err != nil {
+			return fmt.Errorf("could not migrate bundles to full digests: %v", err)
+		}
+		if i.verbose && migrated > 0 {
+			ohai.Fohaif(i.w, "Migrated %d bundle(s) to full digests\n", migrated)
+		}
+	}
+
 	return nil
 }
 
+// migrateBundleDigests re-keys the bundles stored under legacy, truncated digests by their full SHA-256 digest.
+//
+// Index entries whose bundle file is missing are left alone. It returns the number of migrated bundles.
+func migrateBundleDigests(h home.Home) (int, error) {
+	lock, err := repo.LockIndex(h.Repositories())
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Release()
+
+	index, err := repo.LoadIndex(h.Repositories())
+	if err != nil {
+		return 0, err
+	}
+
+	legacy := map[string]bool{}
+	for _, versions := range index {
+		for _, d := range versions {
+			if _, err := godigest.Parse(d); err != nil {
+				legacy[d] = true
+			}
+		}
+	}
+
+	migrated := []string{}
+	for old := range legacy {
+		data, err := ioutil.ReadFile(h.BundleFile(old))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+
+		d := digest.OfBuffer(data).String()
+		if err := storeBundle(h, d, data); err != nil {
+			return 0, err
+		}
+		index.Rekey(old, d)
+		migrated = append(migrated, old)
+	}
+
+	if len(migrated) == 0 {
+		return 0, nil
+	}
+	if err := index.WriteFile(h.Repositories(), 0644); err != nil {
+		return 0, err
+	}
+	for _, old := range migrated {
+		if err := os.Remove(h.BundleFile(old)); err != nil {
+			return len(migrated), err
+		}
+	}
+	return len(migrated), nil
+}
+
 func ensureDirectories(dirs []string) error {
 	for _, dir := range dirs {
 		if fi, err := os.Stat(dir); err != nil {
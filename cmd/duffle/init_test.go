@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/crypto/digest"
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+func TestInitMigratesLegacyDigests(t *testing.T) {
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+	is := assert.New(t)
+
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "tests", "testdata", "bundles", "foo.json"))
+	is.NoError(err)
+	full := digest.OfBuffer(data).String()
+	// bundles used to be stored under the first 40 characters of the hex-encoded SHA-256 of their content
+	legacy := digest.OfBuffer(data).Encoded()[:40]
+	is.True(digest.IsLegacy(legacy))
+	is.NoError(ioutil.WriteFile(filepath.Join(testHome.Bundles(), legacy), data, 0644))
+
+	index := repo.Index{}
+	index.Add("foo", "1.0.0", legacy)
+	index.Add("foo", "1.0.1", legacy)
+	index.Add("bar", "1.0.0", "sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	is.NoError(index.WriteFile(testHome.Repositories(), 0644))
+
+	out := bytes.NewBuffer(nil)
+	i := &initCmd{w: out, verbose: true}
+	is.NoError(i.run())
+	is.Contains(out.String(), "Migrated 1 bundle(s) to full digests")
+
+	index, err = repo.LoadIndex(testHome.Repositories())
+	is.NoError(err)
+	stored, err := index.Get("foo", "1.0.0")
+	is.NoError(err)
+	is.Equal(full, stored)
+	is.Equal(2, index.References(full))
+	is.FileExists(testHome.BundleFile(full))
+	_, err = os.Stat(filepath.Join(testHome.Bundles(), legacy))
+	is.True(os.IsNotExist(err))
+
+	// references made with the legacy digest still resolve to the migrated bundle
+	stored, err = index.GetDigest("foo", legacy)
+	is.NoError(err)
+	is.Equal(full, stored)
+	versions, ok := index.GetVersions(legacy)
+	is.True(ok)
+	is.Len(versions, 1)
+	is.Equal(full, versions[0].Digest)
+
+	// entries without a stored bundle are left alone
+	bar, err := index.Get("bar", "1.0.0")
+	is.NoError(err)
+	is.Equal("sha256:0000000000000000000000000000000000000000000000000000000000000000", bar)
+
+	// running init again is a no-op
+	out.Reset()
+	is.NoError(i.run())
+	is.NotContains(out.String(), "Migrated")
+}
@@ -10,12 +10,16 @@ import (
 	"strings"
 
 	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/parameters"
+	"github.com/cnabio/duffle/pkg/reference"
 	"github.com/cnabio/duffle/pkg/repo"
 
+	"github.com/BurntSushi/toml"
 	"github.com/cnabio/cnab-go/action"
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-go/bundle/definition"
 	"github.com/cnabio/cnab-go/claim"
+	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 )
 
@@ -28,6 +32,9 @@ Example:
 	$ duffle install my_release example:0.1.0
 	$ duffle status my_release
 
+A bundle can also be pinned to the digest it is stored under, as listed by 'duffle bundle list':
+	$ duffle install my_release example@sha256:<digest>
+
 Note: To install a bundle, use $ duffle bundle install or $ duffle install. They are aliases for the same action.
 
 If the bundle has been relocated, you can pass the relocation mapping
@@ -54,6 +61,23 @@ Windows Example:
 You can also load the bundle.json file directly:
 
 	$ duffle install dev_bundle path/to/bundle.json --bundle-is-file
+
+If the bundle declares dependencies with the io.cnab.dependencies extension, each dependency
+is resolved to the highest version of its bundle in the local bundle store that satisfies its
+version ranges, and installed first, as an installation named after the installation and the
+alias of the dependency, such as my_release-mysql. Dependencies are installed with the default
+values of their parameters and the credential sets given with '--credentials'. Use
+'duffle bundle deps' to show the dependencies of a bundle.
+
+Bundles that list extensions Duffle does not support in their required extensions are
+refused. Use 'duffle version --extensions' to list the supported extensions.
+
+With '--dry-run', the fully resolved operation that would be sent to the driver is printed
+with credential values masked, along with the claim that would be stored. Neither the driver
+nor the claim store is touched.
+
+With '--interactive', you are prompted for the value of each required parameter that was not
+set with '--parameters', '--set' or '--set-file'. Values of write-only parameters are not echoed.
 `
 
 type installCmd struct {
@@ -69,10 +93,20 @@ type installCmd struct {
 	bundleIsFile      bool
 	name              string
 	relocationMapping string
+	interactive       bool
+	dryRun            bool
+	outputDir         string
+	control           operationControl
+
+	// params are parameter values set by other commands, such as duffle stack, on top of the values from flags.
+	params map[string]interface{}
+
+	// prompt asks for the values of missing parameters if interactive is set.
+	prompt parameterPrompter
 }
 
 func newInstallCmd(w io.Writer) *cobra.Command {
-	install := &installCmd{out: w}
+	install := &installCmd{out: w, prompt: surveyParameterPrompt}
 
 	cmd := &cobra.Command{
 		Use:   "install [NAME] [BUNDLE]",
@@ -91,10 +125,14 @@ func newInstallCmd(w io.Writer) *cobra.Command {
 	f.BoolVarP(&install.bundleIsFile, "bundle-is-file", "f", false, "Indicates that the bundle source is a file path")
 	f.StringVarP(&install.relocationMapping, "relocation-mapping", "m", "", "Path of relocation mapping JSON file")
 	f.StringVarP(&install.driver, "driver", "d", "docker", "Specify a driver name")
-	f.StringVarP(&install.valuesFile, "parameters", "p", "", "Specify file containing parameters. Formats: toml, MORE SOON")
+	f.StringVarP(&install.valuesFile, "parameters", "p", "", "Specify a file containing parameters, or the name of a parameter set. File formats: json, yaml, toml")
 	f.StringArrayVarP(&install.credentialsFiles, "credentials", "c", []string{}, "Specify credentials to use inside the bundle. This can be a credentialset name or a path to a file.")
 	f.StringArrayVarP(&install.setParams, "set", "s", []string{}, "Set individual parameters as NAME=VALUE pairs")
 	f.StringArrayVarP(&install.setFiles, "set-file", "i", []string{}, "Set individual parameters from file content as NAME=SOURCE-PATH pairs")
+	f.BoolVar(&install.interactive, "interactive", false, "Prompt for the values of required parameters that were not set")
+	f.StringVar(&install.outputDir, "output-dir", "", "Write the outputs of the bundle to files in this directory")
+	install.control.addFlags(f)
+	f.BoolVar(&install.dryRun, "dry-run", false, "Print the operation and the claim that would be created, without running the driver")
 
 	return cmd
 }
@@ -104,6 +142,14 @@ func (i *installCmd) run() error {
 	if err != nil {
 		return err
 	}
+	if !i.dryRun {
+		lease, err := lockClaim(i.name, claim.ActionInstall)
+		if err != nil {
+			return err
+		}
+		defer unlockClaim(i.out, lease)
+	}
+
 	// look in claims store for another claim with the same name
 	_, err = claimStorage().Read(i.name)
 	if err != claim.ErrClaimNotFound {
@@ -122,7 +168,12 @@ func (i *installCmd) run() error {
 		return err
 	}
 
-	driverImpl, err := prepareDriver(i.driver)
+	driverImpl, err := prepareBundleDriver(i.driver, bun, i.control)
+	if err != nil {
+		return err
+	}
+
+	deps, err := resolveDependencies(i.home, bun)
 	if err != nil {
 		return err
 	}
@@ -140,9 +191,22 @@ func (i *installCmd) run() error {
 	}
 
 	c.Bundle = bun
+	rememberCredentialSets(c, i.credentialsFiles)
 	// calculateParamValues determines if values can be changed in later actions, but we don't have
 	// previous values so install passes nil.
-	c.Parameters, err = calculateParamValues(bun, i.valuesFile, i.setParams, i.setFiles)
+	vals, err := collectParamValues(bun, i.valuesFile, i.setParams, i.setFiles)
+	if err != nil {
+		return err
+	}
+	if err := mergeParams(vals, i.params, bun); err != nil {
+		return err
+	}
+	if i.interactive {
+		if err := promptForMissingParameters(bun, vals, i.prompt); err != nil {
+			return err
+		}
+	}
+	c.Parameters, err = bundle.ValuesOrDefaults(vals, bun)
 	if err != nil {
 		return err
 	}
@@ -152,11 +216,35 @@ func (i *installCmd) run() error {
 		return err
 	}
 
+	if i.dryRun {
+		printDependencyPlan(i.out, i.name, deps)
+		dryRun := &dryRunDriver{Driver: driverImpl}
+		inst := &action.Install{Driver: dryRun}
+		if err := inst.Run(c, creds, setOut(i.out), opRelocator); err != nil {
+			return fmt.Errorf("Install step failed: %v", err)
+		}
+		return dryRun.printPlan(i.out, c, creds, claimStore)
+	}
+
+	depNames, err := i.installDependencies(deps)
+	if err != nil {
+		return err
+	}
+	rememberDependencies(c, depNames)
+
+	oplog, err := startOperationLog(i.home, c, claim.ActionInstall, i.out, creds)
+	if err != nil {
+		return err
+	}
+	controlled, release := i.control.control(driverImpl, oplog)
+	defer release()
 	inst := &action.Install{
-		Driver: driverImpl,
+		Driver: controlled,
 	}
 	fmt.Fprintf(i.out, "Executing install action...\n")
-	err = inst.Run(c, creds, setOut(i.out), opRelocator)
+	err = inst.Run(c, creds, setOut(oplog), opRelocator)
+	recordCancellation(c, err)
+	finishOperationLog(i.out, oplog, c)
 
 	// Even if the action fails, we want to store a claim. This is because
 	// we cannot know, based on a failure, whether or not any resources were
@@ -165,7 +253,13 @@ func (i *installCmd) run() error {
 	if err != nil {
 		return fmt.Errorf("Install step failed: %v", err)
 	}
-	return err2
+	if err2 != nil {
+		return err2
+	}
+	if i.outputDir != "" {
+		return writeOutputs(i.out, i.outputDir, *c)
+	}
+	return nil
 }
 
 func getBundleFilepath(bun, homePath string) (string, error) {
@@ -181,16 +275,27 @@ func getBundleFilepath(bun, homePath string) (string, error) {
 		return "", fmt.Errorf("cannot open %s: %v", home.Repositories(), err)
 	}
 
-	tag := ref.Tag()
-	if ref.Tag() == "latest" {
-		tag = ""
+	digest, err := lookupDigest(index, ref)
+	if err != nil {
+		return "", fmt.Errorf("could not find %s in %s: %v", ref, home.Repositories(), err)
 	}
+	return home.BundleFile(digest), nil
+}
 
-	digest, err := index.Get(ref.Name(), tag)
-	if err != nil {
-		return "", fmt.Errorf("could not find %s:%s in %s: %v", ref.Name(), ref.Tag(), home.Repositories(), err)
+// lookupDigest returns the digest of the stored bundle that ref points at.
+//
+// A digest reference must point at one of the versions stored under its name. A tagged reference is looked up by
+// version, where the "latest" tag stands for the highest version.
+func lookupDigest(index repo.Index, ref reference.Named) (string, error) {
+	if digested, ok := ref.(reference.Digested); ok {
+		return index.GetDigest(ref.Name(), digested.Digest().String())
+	}
+
+	tag := ""
+	if tagged, ok := ref.(reference.Tagged); ok && tagged.Tag() != "latest" {
+		tag = tagged.Tag()
 	}
-	return filepath.Join(home.Bundles(), digest), nil
+	return index.Get(ref.Name(), tag)
 }
 
 // overrides parses the --set data and returns values that should override other params.
@@ -208,8 +313,7 @@ func overrides(overrides []string, parameters map[string]bundle.Parameter, schem
 		parameterName := pair[0]
 		overrideValue := pair[1]
 
-		parameter, ok := parameters[parameterName]
-		if !ok {
+		if _, ok := parameters[parameterName]; !ok {
 			return res, fmt.Errorf("parameter %s not defined in bundle", parameterName)
 		}
 
@@ -217,37 +321,127 @@ func overrides(overrides []string, parameters map[string]bundle.Parameter, schem
 			return res, fmt.Errorf("parameter %q specified multiple times", parameterName)
 		}
 
-		schema, ok := schemas[parameter.Definition]
-		if !ok {
-			return res, fmt.Errorf("definition %q of parameter %q is not present in bundle", parameter.Definition, parameterName)
-		}
-
 		var err error
-		res[parameterName], err = schema.ConvertValue(overrideValue)
+		res[parameterName], err = convertParameter(parameterName, overrideValue, parameters, schemas)
 		if err != nil {
-			return res, fmt.Errorf("cannot use %s as value of %s: %s", overrideValue, parameterName, err)
+			return res, err
 		}
 	}
 	return res, nil
 }
 
+// parseValues reads parameter values from a JSON, YAML or TOML file, as determined by its extension.
+//
+// Files with any other extension are read as JSON.
 func parseValues(file string) (map[string]interface{}, error) {
 	vals := map[string]interface{}{}
 	f, err := ioutil.ReadFile(file)
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(f, &vals); err != nil {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(f, &vals)
+	case ".toml":
+		// TOML values are decoded into the same types as JSON values, so that they are validated and coerced alike
+		var tvals map[string]interface{}
+		if err = toml.Unmarshal(f, &tvals); err == nil {
+			if f, err = json.Marshal(tvals); err == nil {
+				err = json.Unmarshal(f, &vals)
+			}
+		}
+	default:
+		err = json.Unmarshal(f, &vals)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse parameters from %s: %v", file, err)
+	}
+	return vals, nil
+}
+
+// loadParameters loads the parameter values from the given parameters file or, if there is no such file, from the
+// parameter set with that name in HOME.
+func loadParameters(file string, bun *bundle.Bundle) (map[string]interface{}, error) {
+	if fileExists(file) {
+		return parseValues(file)
+	}
+
+	paramDir := home.Home(homePath()).Parameters()
+	pset, err := parameters.Load(findParams(paramDir, file))
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a parameters file nor a parameter set: %v", file, err)
+	}
+	res, err := pset.Resolve()
+	if err != nil {
 		return nil, err
 	}
+
+	vals := map[string]interface{}{}
+	for name, val := range res {
+		if _, ok := bun.Parameters[name]; !ok {
+			// parameter sets may be shared by bundles with different parameters
+			continue
+		}
+		vals[name], err = convertParameter(name, val, bun.Parameters, bun.Definitions)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return vals, nil
 }
 
+// convertParameter converts the string value of a parameter to the type of its definition.
+func convertParameter(name, val string, parameters map[string]bundle.Parameter, schemas definition.Definitions) (interface{}, error) {
+	parameter, ok := parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("parameter %s not defined in bundle", name)
+	}
+	schema, ok := schemas[parameter.Definition]
+	if !ok {
+		return nil, fmt.Errorf("definition %q of parameter %q is not present in bundle", parameter.Definition, name)
+	}
+	v, err := schema.ConvertValue(val)
+	if err != nil {
+		return nil, fmt.Errorf("cannot use %s as value of %s: %s", val, name, err)
+	}
+	return v, nil
+}
+
+// mergeParams adds params to vals. String values are converted to the types of their parameters, as the values of
+// --set are.
+func mergeParams(vals, params map[string]interface{}, bun *bundle.Bundle) error {
+	for name, val := range params {
+		if s, ok := val.(string); ok {
+			v, err := convertParameter(name, s, bun.Parameters, bun.Definitions)
+			if err != nil {
+				return err
+			}
+			val = v
+		} else if _, ok := bun.Parameters[name]; !ok {
+			return fmt.Errorf("parameter %s not defined in bundle", name)
+		}
+		vals[name] = val
+	}
+	return nil
+}
+
+// calculateParamValues determines the values of the bundle's parameters from the given parameters file or set,
+// --set and --set-file flags, and the defaults of the bundle.
 func calculateParamValues(bun *bundle.Bundle, valuesFile string, setParams, setFilePaths []string) (map[string]interface{}, error) {
+	vals, err := collectParamValues(bun, valuesFile, setParams, setFilePaths)
+	if err != nil {
+		return vals, err
+	}
+	return bundle.ValuesOrDefaults(vals, bun)
+}
+
+// collectParamValues gathers the parameter values given by the user, without applying defaults or checking that
+// required parameters are set.
+func collectParamValues(bun *bundle.Bundle, valuesFile string, setParams, setFilePaths []string) (map[string]interface{}, error) {
 	vals := map[string]interface{}{}
 	if valuesFile != "" {
 		var err error
-		vals, err = parseValues(valuesFile)
+		vals, err = loadParameters(valuesFile, bun)
 		if err != nil {
 			return vals, err
 		}
@@ -283,5 +477,5 @@ func calculateParamValues(bun *bundle.Bundle, valuesFile string, setParams, setF
 		vals[parts[0]] = string(content)
 	}
 
-	return bundle.ValuesOrDefaults(vals, bun)
+	return vals, nil
 }
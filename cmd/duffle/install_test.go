@@ -1,17 +1,21 @@
 package main
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
 
 	"github.com/cnabio/cnab-go/bundle/definition"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/extensions"
 )
 
 func TestGetBundle(t *testing.T) {
@@ -92,3 +96,80 @@ func TestOverrides(t *testing.T) {
 	_, err = overrides([]string{"bad=worse"}, params, defs)
 	is.Error(err)
 }
+
+func TestGetBundleByDigest(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	_, older := storeTestBundle(t, "foo", "1.0.0")
+	is.NoError(recordBundleReference(testHome, "foo", "1.0.0", older))
+	_, newer := storeTestBundle(t, "foo", "1.1.0")
+	is.NoError(recordBundleReference(testHome, "foo", "1.1.0", newer))
+	_, other := storeTestBundle(t, "bar", "1.0.0")
+	is.NoError(recordBundleReference(testHome, "bar", "1.0.0", other))
+
+	file, err := getBundleFilepath("foo", testHome.String())
+	is.NoError(err)
+	is.Equal(testHome.BundleFile(newer), file)
+
+	file, err = getBundleFilepath("foo@"+older, testHome.String())
+	is.NoError(err)
+	is.Equal(testHome.BundleFile(older), file)
+
+	file, err = getBundleFilepath("foo:1.1.0@"+older, testHome.String())
+	is.NoError(err, "the digest takes precedence over the tag")
+	is.Equal(testHome.BundleFile(older), file)
+
+	_, err = getBundleFilepath("foo@"+other, testHome.String())
+	is.Error(err)
+	is.Contains(err.Error(), "no bundle with the given digest found")
+}
+
+func TestInstallRefusesUnsupportedRequiredExtensions(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	bun := dryRunTestBundle()
+	bun.Custom = map[string]interface{}{"com.example.backups": map[string]interface{}{"schedule": "daily"}}
+	bun.RequiredExtensions = []string{"com.example.backups"}
+	data, err := json.Marshal(bun)
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	install := &installCmd{bundle: bundleFile, bundleIsFile: true, name: "backed-up", home: testHome, out: ioutil.Discard, driver: "debug"}
+	is.EqualError(install.run(), "bundle dryrun requires the extension com.example.backups, which Duffle does not support; run 'duffle version --extensions' to list the supported extensions")
+	_, err = claimStorage().Read("backed-up")
+	is.Equal(claim.ErrClaimNotFound, err)
+
+	// extensions that are declared but not required are ignored
+	bun.RequiredExtensions = nil
+	data, err = json.Marshal(bun)
+	is.NoError(err)
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+	is.NoError(install.run())
+}
+
+func TestInstallRefusesPrivilegedBundleWithoutConsent(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	bun := dryRunTestBundle()
+	bun.Custom = map[string]interface{}{extensions.DockerKey: map[string]interface{}{"privileged": true}}
+	data, err := json.Marshal(bun)
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	install := &installCmd{bundle: bundleFile, bundleIsFile: true, name: "privileged", home: testHome, out: ioutil.Discard, driver: "docker"}
+	is.EqualError(install.run(), "extension io.cnab.docker: bundle dryrun asks to run its invocation image in a privileged container, which has root access to the docker host; pass --allow-privileged to run it anyway")
+	_, err = claimStorage().Read("privileged")
+	is.Equal(claim.ErrClaimNotFound, err)
+
+	// other drivers do not run privileged containers
+	install.driver = "debug"
+	is.NoError(install.run())
+}
@@ -3,14 +3,49 @@ package main
 import (
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
+	"github.com/cnabio/cnab-go/claim"
 	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
 )
 
 type listCmd struct {
-	out   io.Writer
-	short bool
+	out    io.Writer
+	short  bool
+	output outputFormat
+}
+
+// installationItem is an installation as printed by duffle list.
+type installationItem struct {
+	Name           string    `json:"name"`
+	Bundle         string    `json:"bundle"`
+	Version        string    `json:"version"`
+	Installed      time.Time `json:"installed"`
+	Modified       time.Time `json:"modified"`
+	LastAction     string    `json:"lastAction"`
+	LastStatus     string    `json:"lastStatus"`
+	CredentialSets []string  `json:"credentialSets"`
+}
+
+func newInstallationItem(c claim.Claim) installationItem {
+	item := installationItem{
+		Name:           c.Name,
+		Installed:      c.Created,
+		Modified:       c.Modified,
+		LastAction:     c.Result.Action,
+		LastStatus:     c.Result.Status,
+		CredentialSets: readClaimData(c).CredentialSets,
+	}
+	if c.Bundle != nil {
+		item.Bundle = c.Bundle.Name
+		item.Version = c.Bundle.Version
+	}
+	if item.CredentialSets == nil {
+		item.CredentialSets = []string{}
+	}
+	return item
 }
 
 func newListCmd(out io.Writer) *cobra.Command {
@@ -25,11 +60,16 @@ func newListCmd(out io.Writer) *cobra.Command {
 
 	f := cmd.Flags()
 	f.BoolVarP(&list.short, "short", "s", false, "output shorter listing format")
+	list.output.addFlag(f)
 
 	return cmd
 }
 
 func (l *listCmd) run() error {
+	if err := l.output.validateShort(l.short); err != nil {
+		return err
+	}
+
 	if l.short {
 		claims, err := claimStorage().List()
 		if err != nil {
@@ -38,24 +78,29 @@ func (l *listCmd) run() error {
 		for _, claim := range claims {
 			fmt.Fprintln(l.out, claim)
 		}
+		return nil
+	}
 
-	} else {
-		claims, err := claimStorage().ReadAll()
-		if err != nil {
-			return err
-		}
+	claims, err := claimStorage().ReadAll()
+	if err != nil {
+		return err
+	}
+	items := make([]installationItem, 0, len(claims))
+	for _, c := range claims {
+		items = append(items, newInstallationItem(c))
+	}
 
+	return l.output.print(l.out, items, func() error {
 		table := uitable.New()
 		table.MaxColWidth = 50
 		table.Wrap = true
 
-		table.AddRow("NAME", "BUNDLE", "INSTALLED", "LAST ACTION", "LAST STATUS")
-		for _, cl := range claims {
-			table.AddRow(cl.Name, cl.Bundle.Name, cl.Created, cl.Result.Action, cl.Result.Status)
+		table.AddRow("NAME", "BUNDLE", "INSTALLED", "LAST ACTION", "LAST STATUS", "CREDENTIALS")
+		for _, item := range items {
+			table.AddRow(item.Name, item.Bundle, item.Installed, item.LastAction, item.LastStatus, strings.Join(item.CredentialSets, ", "))
 		}
 
 		fmt.Fprintln(l.out, table)
-	}
-
-	return nil
+		return nil
+	})
 }
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/ohai"
+	"github.com/cnabio/duffle/pkg/redact"
+)
+
+const logsDesc = `Display the output of the operations performed on an installation.
+
+The output of every install, upgrade, uninstall, status and custom action is saved
+in the Duffle home, with the values of credentials redacted. The log of a revision
+holds the output of the operation that created the revision, followed by the output
+of any operations that did not modify the installation afterwards.
+
+By default the log of the latest revision is shown. If '--follow' is set and an
+operation on the installation is still running, its output is streamed until it
+finishes.
+
+Ex. $ duffle logs my-app
+    $ duffle logs my-app --revision 01DGDTQ7M0RZ0JMNZD5XH2DXQ0
+    $ duffle logs my-app --follow
+`
+
+const (
+	// logExt is the extension of the log of a finished operation.
+	logExt = ".log"
+	// partialLogExt is the extension of the log of an operation that is still running.
+	partialLogExt = ".log.partial"
+)
+
+// followInterval is how often a running operation's log is checked for new output with 'duffle logs --follow'.
+var followInterval = 250 * time.Millisecond
+
+type logsCmd struct {
+	home     home.Home
+	out      io.Writer
+	name     string
+	revision string
+	follow   bool
+}
+
+func newLogsCmd(w io.Writer) *cobra.Command {
+	logs := &logsCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "logs NAME",
+		Short: "display the output of the operations on an installation",
+		Long:  logsDesc,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logs.name = args[0]
+			logs.home = home.Home(homePath())
+			return logs.run()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&logs.revision, "revision", "r", "", "display the log of this revision instead of the latest one")
+	flags.BoolVarP(&logs.follow, "follow", "f", false, "stream the output of a running operation")
+
+	return cmd
+}
+
+func (l *logsCmd) run() error {
+	dir := claimLogDir(l.home, l.name)
+
+	if l.follow && l.revision == "" {
+		running, err := latestLog(dir, partialLogExt)
+		if err != nil {
+			return err
+		}
+		if running != "" {
+			return followLog(l.out, running)
+		}
+	}
+
+	path := filepath.Join(dir, l.revision+logExt)
+	if l.revision == "" {
+		latest, err := latestLog(dir, logExt)
+		if err != nil {
+			return err
+		}
+		if latest == "" {
+			return fmt.Errorf("no logs found for %q", l.name)
+		}
+		path = latest
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no log found for revision %q of %q", l.revision, l.name)
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(l.out, f)
+	return err
+}
+
+// claimLogDir returns the directory the logs of the named installation are kept in.
+func claimLogDir(h home.Home, name string) string {
+	return filepath.Join(h.Logs(), "claims", name)
+}
+
+// latestLog returns the path of the most recent log in dir with the given extension, or "" if there is none.
+//
+// Logs are named after claim revisions, which sort by time.
+func latestLog(dir, ext string) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	names := []string{}
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	sort.Strings(names)
+	return filepath.Join(dir, names[len(names)-1]), nil
+}
+
+// followLog copies the log of a running operation to w until the operation finishes, which is when its partial log
+// is moved away.
+func followLog(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for {
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			// the operation finished; whatever was written last is still readable through f
+			_, err := io.Copy(w, f)
+			return err
+		}
+		time.Sleep(followInterval)
+	}
+}
+
+// operationLog tees the output of an operation into the log of the installation it is performed on. Credential
+// values are redacted before anything is written to disk.
+//
+// While the operation runs, the output is written to a partial log. finish moves it to the log of the claim's
+// revision after the operation.
+type operationLog struct {
+	out      io.Writer
+	file     *os.File
+	redacted *redact.Writer
+	dir      string
+
+	// err is the first error writing to the log. It does not interrupt the output of the operation.
+	err error
+}
+
+// startOperationLog starts the log of an action on the installation c, which also writes the output to out.
+func startOperationLog(h home.Home, c *claim.Claim, action string, out io.Writer, creds credentials.Set) (*operationLog, error) {
+	dir := claimLogDir(h, c.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, claim.ULID()+partialLogExt), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the operation log: %v", err)
+	}
+
+	secrets := make([]string, 0, len(creds))
+	for _, v := range creds {
+		secrets = append(secrets, v)
+	}
+	l := &operationLog{
+		out:      out,
+		file:     f,
+		redacted: redact.NewWriter(f, secrets),
+		dir:      dir,
+	}
+	fmt.Fprintf(l.redacted, "==> %s of %s started at %s\n", action, c.Name, time.Now().Format(time.RFC3339))
+	return l, nil
+}
+
+// Write writes p to the output and to the log.
+func (l *operationLog) Write(p []byte) (int, error) {
+	if l.err == nil {
+		_, l.err = l.redacted.Write(p)
+	}
+	return l.out.Write(p)
+}
+
+// finish closes the log and stores it as the log of the given claim revision. If the operation did not create a new
+// revision, its output is appended to the existing log of the revision.
+func (l *operationLog) finish(revision string) error {
+	err := l.err
+	if rerr := l.redacted.Close(); err == nil {
+		err = rerr
+	}
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	partial := l.file.Name()
+	target := filepath.Join(l.dir, revision+logExt)
+	if _, err := os.Stat(target); os.IsNotExist(err) {
+		return os.Rename(partial, target)
+	}
+
+	data, err := ioutil.ReadFile(partial)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(partial)
+}
+
+// finishOperationLog finishes the log of an operation that produced claim c. A failure to save the log is reported
+// to w rather than failing the operation.
+func finishOperationLog(w io.Writer, l *operationLog, c *claim.Claim) {
+	if err := l.finish(c.Revision); err != nil {
+		ohai.Fwarningf(w, "could not save the log of the operation: %v\n", err)
+	}
+}
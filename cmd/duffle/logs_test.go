@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallWritesRedactedLog(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	data, err := json.Marshal(dryRunTestBundle())
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	data, err = yaml.Marshal(credentials.CredentialSet{
+		Name: "secret",
+		Credentials: []credentials.CredentialStrategy{
+			{Name: "token", Source: credentials.Source{Value: "s3cr3t"}},
+		},
+	})
+	is.NoError(err)
+	credFile := filepath.Join(testHome.String(), "secret.yaml")
+	is.NoError(ioutil.WriteFile(credFile, data, 0644))
+
+	out := bytes.NewBuffer(nil)
+	install := &installCmd{
+		bundle:           bundleFile,
+		bundleIsFile:     true,
+		name:             "logged",
+		home:             testHome,
+		out:              out,
+		driver:           "debug",
+		credentialsFiles: []string{credFile},
+	}
+	is.NoError(install.run())
+	// the debug driver dumps the operation, credentials included
+	is.Contains(out.String(), `"TOKEN": "*****"`)
+	is.NotContains(out.String(), "s3cr3t")
+
+	c, err := claimStorage().Read("logged")
+	is.NoError(err)
+
+	logs := bytes.NewBuffer(nil)
+	is.NoError((&logsCmd{home: testHome, out: logs, name: "logged"}).run())
+	is.Contains(logs.String(), "==> install of logged started at")
+	is.Contains(logs.String(), `"TOKEN": "*****"`)
+	is.NotContains(logs.String(), "s3cr3t", "credentials must be redacted in the log")
+
+	byRevision := bytes.NewBuffer(nil)
+	is.NoError((&logsCmd{home: testHome, out: byRevision, name: "logged", revision: c.Revision}).run())
+	is.Equal(logs.String(), byRevision.String())
+
+	err = (&logsCmd{home: testHome, out: ioutil.Discard, name: "logged", revision: "nope"}).run()
+	is.EqualError(err, `no log found for revision "nope" of "logged"`)
+	err = (&logsCmd{home: testHome, out: ioutil.Discard, name: "missing"}).run()
+	is.EqualError(err, `no logs found for "missing"`)
+}
+
+func TestOperationLogAppendsToRevision(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	c, err := claim.New("appended")
+	is.NoError(err)
+
+	for _, action := range []string{claim.ActionInstall, claim.ActionStatus} {
+		l, err := startOperationLog(testHome, c, action, ioutil.Discard, credentials.Set{"password": "hunter2"})
+		is.NoError(err)
+		_, err = l.Write([]byte("password is hunter2\n"))
+		is.NoError(err)
+		is.NoError(l.finish(c.Revision))
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(claimLogDir(testHome, "appended"), c.Revision+logExt))
+	is.NoError(err)
+	is.Contains(string(data), "==> install of appended")
+	is.Contains(string(data), "==> status of appended")
+	is.Equal(2, bytes.Count(data, []byte("password is *****\n")))
+
+	partial, err := latestLog(claimLogDir(testHome, "appended"), partialLogExt)
+	is.NoError(err)
+	is.Empty(partial, "partial logs are moved away when an operation finishes")
+}
+
+func TestFollowLog(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	defer func(interval time.Duration) { followInterval = interval }(followInterval)
+	followInterval = 10 * time.Millisecond
+	c, err := claim.New("followed")
+	is.NoError(err)
+	l, err := startOperationLog(testHome, c, claim.ActionInstall, ioutil.Discard, nil)
+	is.NoError(err)
+
+	go func() {
+		for i := 0; i < 3; i++ {
+			l.Write([]byte("working\n"))
+			time.Sleep(20 * time.Millisecond)
+		}
+		l.Write([]byte("done\n"))
+		l.finish(c.Revision)
+	}()
+
+	out := bytes.NewBuffer(nil)
+	is.NoError((&logsCmd{home: testHome, out: out, name: "followed", follow: true}).run())
+	is.Contains(out.String(), "working\nworking\nworking\ndone\n")
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -16,10 +17,14 @@ import (
 	"github.com/cnabio/cnab-go/credentials"
 	"github.com/cnabio/cnab-go/driver"
 	"github.com/cnabio/cnab-go/driver/lookup"
-	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 
+	"github.com/cnabio/duffle/pkg/claimstore"
+	"github.com/cnabio/duffle/pkg/credprovider"
 	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/extensions"
+	"github.com/cnabio/duffle/pkg/filestore"
 	"github.com/cnabio/duffle/pkg/reference"
 )
 
@@ -54,7 +59,7 @@ func defaultDuffleHome() string {
 // claimStorage returns a claim store for accessing claims.
 func claimStorage() claim.Store {
 	h := home.Home(homePath())
-	return claim.NewClaimStore(crud.NewFileSystemStore(h.Claims(), "json"))
+	return claim.NewClaimStore(claimstore.New(filestore.New(h.Claims(), "json"), h.EncryptionKey()))
 }
 
 // loadCredentials loads a set of credentials from HOME.
@@ -70,10 +75,17 @@ func loadCredentials(files []string, b *bundle.Bundle) (map[string]string, error
 	// calculate its credentials. Then we insert them into the creds map in the order
 	// in which they were supplied on the CLI.
 	for _, file := range files {
-		cset, err := credentials.Load(findCreds(credDir, file))
+		data, err := readCredentialSet(findCreds(credDir, file))
 		if err != nil {
 			return creds, err
 		}
+		cset := &credentials.CredentialSet{}
+		if err := yaml.Unmarshal(data, cset); err != nil {
+			return creds, err
+		}
+		if err := credprovider.Default.ResolveCredentialSet(data, cset); err != nil {
+			return creds, fmt.Errorf("credential set %s: %v", cset.Name, err)
+		}
 		res, err := cset.Resolve()
 		if err != nil {
 			return res, err
@@ -86,6 +98,11 @@ func loadCredentials(files []string, b *bundle.Bundle) (map[string]string, error
 	return creds, credentials.Validate(creds, b.Credentials)
 }
 
+// findParams returns the path of the named parameter set in paramDir, unless file is an existing file.
+func findParams(paramDir string, file string) string {
+	return findCreds(paramDir, file)
+}
+
 func findCreds(credDir string, file string) string {
 	if !fileExists(file) {
 		testPath := filepath.Join(credDir, file+".yaml")
@@ -122,6 +139,37 @@ func prepareDriver(driverName string) (driver.Driver, error) {
 		configureDriver(configurable)
 	}
 
+	return flushingDriver{driverImpl}, nil
+}
+
+// prepareBundleDriver prepares the named driver to run an action of bun under control. It fails if the bundle requires
+// extensions that Duffle does not support, and configures the driver for the extensions the bundle declares, within
+// what control allows.
+func prepareBundleDriver(driverName string, bun *bundle.Bundle, control operationControl) (driver.Driver, error) {
+	if err := extensions.Default.Check(bun); err != nil {
+		if _, ok := err.(*extensions.UnsupportedError); ok {
+			return nil, fmt.Errorf("%v; run 'duffle version --extensions' to list the supported extensions", err)
+		}
+		return nil, err
+	}
+	driverImpl, err := prepareDriver(driverName)
+	if err != nil {
+		return nil, err
+	}
+	inner := driverImpl
+	if flushing, ok := inner.(flushingDriver); ok {
+		inner = flushing.Driver
+	}
+	if err := control.check(inner); err != nil {
+		return nil, err
+	}
+	if err := extensions.Default.Configure(inner, bun, control.extensionOptions()); err != nil {
+		var privileged *extensions.PrivilegedError
+		if errors.As(err, &privileged) {
+			return nil, fmt.Errorf("%v; pass --allow-privileged to run it anyway", err)
+		}
+		return nil, err
+	}
 	return driverImpl, nil
 }
 
@@ -189,10 +237,14 @@ func loadBundle(bundleFile string) (*bundle.Bundle, error) {
 	}
 	return bun, nil
 }
-func getReference(bundleName string) (reference.NamedTagged, error) {
+
+// getReference parses a bundle name into a reference.
+//
+// The reference is either tagged, defaulting to the "latest" tag, or pinned to a digest, as in name@sha256:<hex>.
+func getReference(bundleName string) (reference.Named, error) {
 	var (
 		name string
-		ref  reference.NamedTagged
+		ref  reference.Named
 	)
 
 	parts := strings.SplitN(bundleName, "://", 2)
@@ -214,9 +266,12 @@ func getReference(bundleName string) (reference.NamedTagged, error) {
 			panic(err)
 		}
 	} else {
-		if taggedRef, ok := normalizedRef.(reference.NamedTagged); ok {
-			ref = taggedRef
-		} else {
+		switch r := normalizedRef.(type) {
+		case reference.Canonical:
+			ref = r
+		case reference.NamedTagged:
+			ref = r
+		default:
 			return nil, fmt.Errorf("unsupported image name: %s", normalizedRef.String())
 		}
 	}
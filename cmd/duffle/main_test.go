@@ -9,6 +9,7 @@ import (
 
 	"github.com/cnabio/cnab-go/driver"
 
+	"github.com/cnabio/duffle/pkg/credprovider"
 	"github.com/cnabio/duffle/pkg/duffle/home"
 
 	"github.com/cnabio/cnab-go/bundle"
@@ -32,6 +33,7 @@ func CreateTestHome(t *testing.T) home.Home {
 		testHome.Plugins(),
 		testHome.Claims(),
 		testHome.Credentials(),
+		testHome.Parameters(),
 	}
 	if err := ensureDirectories(dirs); err != nil {
 		t.Fatal(err)
@@ -118,6 +120,41 @@ func TestLoadCredentials(t *testing.T) {
 	is.Equal("cred1", creds["gym-bag"])
 }
 
+type staticProvider map[string]string
+
+func (p staticProvider) Resolve(key string) (string, error) {
+	return p[key], nil
+}
+
+func TestLoadCredentialsFromProvider(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	credprovider.Default.Register("static", func() (credprovider.Provider, error) {
+		return staticProvider{"db#password": "hunter2"}, nil
+	})
+	data := []byte("name: vaulted\ncredentials:\n- name: password\n  source:\n    provider: static\n    key: db#password\n")
+	if err := ioutil.WriteFile(filepath.Join(testHome.Credentials(), "vaulted.yaml"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	bun := &bundle.Bundle{Credentials: map[string]bundle.Credential{
+		"password": {Location: bundle.Location{EnvironmentVariable: "PASSWORD"}, Required: true},
+	}}
+
+	creds, err := loadCredentials([]string{"vaulted"}, bun)
+	is.NoError(err)
+	is.Equal("hunter2", creds["password"])
+
+	data = []byte("name: unknown\ncredentials:\n- name: password\n  source:\n    provider: nope\n    key: db#password\n")
+	if err := ioutil.WriteFile(filepath.Join(testHome.Credentials(), "unknown.yaml"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = loadCredentials([]string{"unknown"}, bun)
+	is.Error(err)
+	is.Contains(err.Error(), `credential set unknown: credential "password": unknown credential provider "nope"`)
+}
+
 func TestFindCreds(t *testing.T) {
 	credDir, err := ioutil.TempDir("", "credTest")
 	if err != nil {
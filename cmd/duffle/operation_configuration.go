@@ -1,15 +1,123 @@
 package main
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/cnabio/cnab-go/action"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
 	"github.com/cnabio/cnab-go/driver"
+
+	"github.com/cnabio/duffle/pkg/redact"
 )
 
+// setOut sends the output of the operation to w, with the values of its credentials and write-only parameters
+// masked.
+//
+// Output that might be the start of a secret is held back until more output arrives. The driver returned by
+// prepareDriver writes it out once the operation has finished.
 func setOut(w io.Writer) action.OperationConfigFunc {
 	return func(op *driver.Operation) error {
-		op.Out = w
+		op.Out = redact.NewWriter(w, operationSecrets(op))
+		return nil
+	}
+}
+
+// flushingDriver writes out the output held back by setOut once the wrapped driver has run an operation.
+type flushingDriver struct {
+	driver.Driver
+}
+
+// Run runs the operation with the wrapped driver and flushes its output.
+func (d flushingDriver) Run(op *driver.Operation) (driver.OperationResult, error) {
+	res, err := d.Driver.Run(op)
+	if out, ok := op.Out.(*redact.Writer); ok {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return res, err
+}
+
+// operationSecrets returns the values of the credentials and write-only parameters that are injected into op.
+func operationSecrets(op *driver.Operation) []string {
+	if op.Bundle == nil {
+		return nil
+	}
+	secrets := []string{}
+	for _, cred := range op.Bundle.Credentials {
+		if v, ok := op.Environment[cred.EnvironmentVariable]; ok && cred.EnvironmentVariable != "" {
+			secrets = append(secrets, v)
+		}
+		if v, ok := op.Files[cred.Path]; ok && cred.Path != "" {
+			secrets = append(secrets, v)
+		}
+	}
+	return append(secrets, writeOnlyValues(op.Bundle, op.Parameters, nil)...)
+}
+
+// claimSecrets returns the values of the write-only parameters and outputs of the claim.
+func claimSecrets(c *claim.Claim) []string {
+	if c.Bundle == nil {
 		return nil
 	}
+	return writeOnlyValues(c.Bundle, c.Parameters, c.Outputs)
+}
+
+// maskClaim returns a copy of c in which the values of write-only parameters and outputs are masked, as well as any
+// occurrence of them in the result message.
+func maskClaim(c claim.Claim) claim.Claim {
+	if c.Bundle == nil {
+		return c
+	}
+	secrets := claimSecrets(&c)
+
+	if c.Parameters != nil {
+		params := make(map[string]interface{}, len(c.Parameters))
+		for name, val := range c.Parameters {
+			params[name] = val
+			if p, ok := c.Bundle.Parameters[name]; ok && isWriteOnlyDefinition(c.Bundle, p.Definition) {
+				params[name] = redact.Mask
+			}
+		}
+		c.Parameters = params
+	}
+
+	if c.Outputs != nil {
+		outputs := make(map[string]interface{}, len(c.Outputs))
+		for name, val := range c.Outputs {
+			outputs[name] = val
+			if o, ok := c.Bundle.Outputs[name]; ok && isWriteOnlyDefinition(c.Bundle, o.Definition) {
+				outputs[name] = redact.Mask
+			}
+		}
+		c.Outputs = outputs
+	}
+
+	c.Result.Message = redact.String(c.Result.Message, secrets)
+	return c
+}
+
+// writeOnlyValues returns the values of the write-only parameters in params and outputs in outputs, as they are
+// printed.
+func writeOnlyValues(bun *bundle.Bundle, params, outputs map[string]interface{}) []string {
+	values := []string{}
+	for name, val := range params {
+		if p, ok := bun.Parameters[name]; ok && isWriteOnlyDefinition(bun, p.Definition) && val != nil {
+			values = append(values, fmt.Sprintf("%v", val))
+		}
+	}
+	for name, val := range outputs {
+		if o, ok := bun.Outputs[name]; ok && isWriteOnlyDefinition(bun, o.Definition) && val != nil {
+			values = append(values, fmt.Sprintf("%v", val))
+		}
+	}
+	return values
+}
+
+// isWriteOnlyDefinition reports whether the named definition of the bundle marks values as sensitive.
+func isWriteOnlyDefinition(bun *bundle.Bundle, name string) bool {
+	schema, ok := bun.Definitions[name]
+	return ok && isWriteOnly(schema)
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/driver"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/redact"
+)
+
+func secretTestBundle() *bundle.Bundle {
+	writeOnly := true
+	return &bundle.Bundle{
+		Name:    "secret",
+		Version: "0.1.0",
+		Definitions: definition.Definitions{
+			"string":   {Type: "string"},
+			"password": {Type: "string", WriteOnly: &writeOnly},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"user":     {Definition: "string"},
+			"password": {Definition: "password"},
+		},
+		Outputs: map[string]bundle.Output{
+			"url":   {Definition: "string", Path: "/cnab/app/outputs/url"},
+			"token": {Definition: "password", Path: "/cnab/app/outputs/token"},
+		},
+		Credentials: map[string]bundle.Credential{
+			"kubeconfig": {Location: bundle.Location{Path: "/root/.kube/config"}},
+			"api-key":    {Location: bundle.Location{EnvironmentVariable: "API_KEY"}},
+		},
+	}
+}
+
+// echoDriver writes the given chunks to the output of the operation.
+type echoDriver struct {
+	driver.Driver
+	chunks []string
+}
+
+func (d echoDriver) Run(op *driver.Operation) (driver.OperationResult, error) {
+	for _, c := range d.chunks {
+		op.Out.Write([]byte(c))
+	}
+	return driver.OperationResult{}, nil
+}
+
+func TestSetOutMasksSecrets(t *testing.T) {
+	is := assert.New(t)
+
+	op := &driver.Operation{
+		Bundle:      secretTestBundle(),
+		Parameters:  map[string]interface{}{"user": "admin", "password": "hunter2"},
+		Environment: map[string]string{"API_KEY": "k3y"},
+		Files:       map[string]string{"/root/.kube/config": "apiVersion: v1"},
+	}
+	var out bytes.Buffer
+	is.NoError(setOut(&out)(op))
+
+	d := flushingDriver{echoDriver{chunks: []string{"user admin, password hun", "ter2, key k3y, config apiVersion: v1, trailing k"}}}
+	_, err := d.Run(op)
+	is.NoError(err)
+	is.Equal("user admin, password *****, key *****, config *****, trailing k", out.String(), "held back output is written once the operation finishes")
+}
+
+func TestMaskClaim(t *testing.T) {
+	is := assert.New(t)
+
+	c := claim.Claim{
+		Name:       "secret",
+		Bundle:     secretTestBundle(),
+		Parameters: map[string]interface{}{"user": "admin", "password": "hunter2"},
+		Outputs:    map[string]interface{}{"url": "https://example.com", "token": "t0k3n"},
+		Result:     claim.Result{Message: "login with hunter2 failed"},
+	}
+	masked := maskClaim(c)
+	is.Equal(map[string]interface{}{"user": "admin", "password": redact.Mask}, masked.Parameters)
+	is.Equal(map[string]interface{}{"url": "https://example.com", "token": redact.Mask}, masked.Outputs)
+	is.Equal("login with ***** failed", masked.Result.Message)
+	is.Equal("hunter2", c.Parameters["password"], "the original claim is not modified")
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/driver"
+	commanddriver "github.com/cnabio/cnab-go/driver/command"
+	"github.com/cnabio/cnab-go/driver/docker"
+	"github.com/docker/cli/cli/command"
+	cliflags "github.com/docker/cli/cli/flags"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/spf13/pflag"
+
+	"github.com/cnabio/duffle/pkg/extensions"
+)
+
+// statusCancelled is the status of a claim whose last action was cancelled by the user.
+const statusCancelled = "cancelled"
+
+// operationLabel is the label on the containers started by the docker driver, which tells the containers of an
+// operation apart so that they can be stopped when it is cancelled.
+const operationLabel = "sh.duffle.operation"
+
+// stopGracePeriod is how long a cancelled operation is given to stop, before Duffle stops waiting for it.
+var stopGracePeriod = 30 * time.Second
+
+// operationControl holds the flags controlling how long an action may run, how often it is retried, and what the
+// invocation image is allowed to do.
+type operationControl struct {
+	timeout         time.Duration
+	retries         int
+	backoff         time.Duration
+	allowPrivileged bool
+}
+
+func (o *operationControl) addFlags(f *pflag.FlagSet) {
+	f.DurationVar(&o.timeout, "timeout", 0, "Cancel the action if it has not finished after this long, for example 30m. 0 means no timeout")
+	f.IntVar(&o.retries, "retries", 0, "Run the invocation image again this many times if it fails")
+	f.DurationVar(&o.backoff, "retry-backoff", 10*time.Second, "How long to wait before the first retry. The wait doubles with each retry")
+	f.BoolVar(&o.allowPrivileged, "allow-privileged", false, "Allow bundles to run their invocation image in a privileged container, with root access to the docker host")
+}
+
+// check fails if d cannot run operations as controlled. Operations of command drivers run in processes Duffle cannot
+// stop, so they cannot time out.
+func (o operationControl) check(d driver.Driver) error {
+	if cd, ok := d.(*commanddriver.Driver); ok && o.timeout > 0 {
+		return fmt.Errorf("the %s driver cannot stop operations, so --timeout cannot be used with it", cd.Name)
+	}
+	return nil
+}
+
+// extensionOptions returns what the user allows the extensions of a bundle to do.
+func (o operationControl) extensionOptions() extensions.Options {
+	return extensions.Options{AllowPrivileged: o.allowPrivileged}
+}
+
+// control returns a driver that runs operations with d, retrying them as configured, and cancelling them once the
+// timeout expires or the process is interrupted with SIGINT or SIGTERM. Messages about retries and cancellation are
+// written to w.
+//
+// The returned function stops listening for signals, and must be called once the action is done.
+func (o operationControl) control(d driver.Driver, w io.Writer) (driver.Driver, func()) {
+	// the output is flushed once, after all attempts
+	flushing, isFlushing := d.(flushingDriver)
+	if isFlushing {
+		d = flushing.Driver
+	}
+
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if o.timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), o.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	cd := &controlledDriver{
+		Driver:  d,
+		ctx:     ctx,
+		timeout: o.timeout,
+		retries: o.retries,
+		backoff: o.backoff,
+		out:     w,
+		force:   make(chan struct{}),
+	}
+	cd.stop = stopperFor(d)
+	if cmdDriver, ok := d.(*commanddriver.Driver); ok {
+		cd.unstoppable = cmdDriver.Name
+	}
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case s := <-sigs:
+			cd.interrupt(s)
+			cancel()
+		case <-done:
+			return
+		}
+		// a second signal stops waiting for the operation to stop
+		select {
+		case <-sigs:
+			close(cd.force)
+		case <-done:
+		}
+	}()
+
+	var controlled driver.Driver = cd
+	if isFlushing {
+		controlled = flushingDriver{cd}
+	}
+	return controlled, func() {
+		signal.Stop(sigs)
+		close(done)
+		cancel()
+	}
+}
+
+// operationCancelledError is returned by a controlled driver when an operation is cancelled or times out.
+type operationCancelledError struct {
+	// reason is the signal that cancelled the operation, if it did not time out.
+	reason string
+	// timeout is the timeout that expired, if the operation timed out.
+	timeout time.Duration
+}
+
+func (e *operationCancelledError) Error() string {
+	if e.timeout > 0 {
+		return fmt.Sprintf("the operation timed out after %s", e.timeout)
+	}
+	return fmt.Sprintf("the operation was cancelled (%s)", e.reason)
+}
+
+// recordCancellation marks the claim as cancelled if err tells that the user cancelled the action. Actions that time
+// out keep their failure status.
+func recordCancellation(c *claim.Claim, err error) {
+	var cancelled *operationCancelledError
+	if errors.As(err, &cancelled) && cancelled.timeout == 0 {
+		c.Result.Status = statusCancelled
+	}
+}
+
+// controlledDriver runs operations with a driver under the control of a context.
+type controlledDriver struct {
+	driver.Driver
+
+	ctx     context.Context
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+	out     io.Writer
+	// force is closed when the user no longer wants to wait for a cancelled operation to stop.
+	force chan struct{}
+	// stop stops an operation, if the driver supports it.
+	stop func() error
+	// unstoppable is the name of the driver if it runs operations in processes that Duffle cannot stop, so that they
+	// are waited for until they finish.
+	unstoppable string
+
+	mu     sync.Mutex
+	reason string
+}
+
+// interrupt records the signal that cancels the operation.
+func (d *controlledDriver) interrupt(s os.Signal) {
+	d.mu.Lock()
+	d.reason = s.String()
+	d.mu.Unlock()
+	if d.unstoppable != "" {
+		fmt.Fprintf(d.out, "Received %s. The %s driver cannot stop the operation, waiting for it to finish. Send it again to stop waiting.\n", s, d.unstoppable)
+		return
+	}
+	fmt.Fprintf(d.out, "Received %s, stopping the operation. Send it again to stop waiting.\n", s)
+}
+
+// Run runs the operation, retrying it if it fails.
+func (d *controlledDriver) Run(op *driver.Operation) (driver.OperationResult, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := d.runOnce(op)
+		if err == nil || d.ctx.Err() != nil || attempt >= d.retries {
+			return res, err
+		}
+		wait := d.backoff << uint(attempt)
+		fmt.Fprintf(d.out, "Attempt %d of %d failed: %v. Retrying in %s...\n", attempt+1, d.retries+1, err, wait)
+		select {
+		case <-time.After(wait):
+		case <-d.ctx.Done():
+			return res, d.cancelled()
+		}
+	}
+}
+
+// runOnce runs the operation, stopping it when the context is done.
+func (d *controlledDriver) runOnce(op *driver.Operation) (driver.OperationResult, error) {
+	if d.ctx.Err() != nil {
+		return driver.OperationResult{}, d.cancelled()
+	}
+
+	type result struct {
+		res driver.OperationResult
+		err error
+	}
+	results := make(chan result, 1)
+	go func() {
+		res, err := d.Driver.Run(op)
+		results <- result{res, err}
+	}()
+
+	select {
+	case r := <-results:
+		if r.err != nil && d.ctx.Err() != nil {
+			return r.res, d.cancelled()
+		}
+		return r.res, r.err
+	case <-d.ctx.Done():
+	}
+
+	if d.stop != nil {
+		if err := d.stop(); err != nil {
+			fmt.Fprintf(d.out, "Could not stop the operation: %v\n", err)
+		}
+	}
+	var grace <-chan time.Time
+	if d.unstoppable == "" {
+		grace = time.After(stopGracePeriod)
+	}
+	select {
+	case r := <-results:
+		return r.res, d.cancelled()
+	case <-d.force:
+	case <-grace:
+	}
+	fmt.Fprintln(d.out, "Stopped waiting for the operation, which may still be running.")
+	abandonedOperations.Store(op.Installation, true)
+	return driver.OperationResult{}, d.cancelled()
+}
+
+// abandonedOperations holds the names of the installations whose operation Duffle stopped waiting for. Their claim
+// lock is kept, as the operation may still be running.
+var abandonedOperations sync.Map
+
+// cancelled returns the error for an operation whose context is done.
+func (d *controlledDriver) cancelled() error {
+	if d.ctx.Err() == context.DeadlineExceeded {
+		return &operationCancelledError{timeout: d.timeout}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &operationCancelledError{reason: d.reason}
+}
+
+// stopperFor returns a function that stops the operations run by d, or nil if d cannot stop them.
+//
+// The containers started by the docker driver are labelled, and stopped by their label. Command drivers cannot be
+// stopped: the command receives an interrupt from the terminal along with Duffle, but not signals sent to Duffle
+// alone.
+func stopperFor(d driver.Driver) func() error {
+	dd, ok := d.(*docker.Driver)
+	if !ok {
+		return nil
+	}
+
+	var b [8]byte
+	rand.Read(b[:])
+	id := hex.EncodeToString(b[:])
+	dd.AddConfigurationOptions(func(cfg *container.Config, _ *container.HostConfig) error {
+		if cfg.Labels == nil {
+			cfg.Labels = map[string]string{}
+		}
+		cfg.Labels[operationLabel] = id
+		return nil
+	})
+	return func() error {
+		return stopDockerContainers(operationLabel + "=" + id)
+	}
+}
+
+// stopDockerContainers stops the running containers with the given label.
+func stopDockerContainers(label string) error {
+	cli, err := command.NewDockerCli()
+	if err != nil {
+		return err
+	}
+	if err := cli.Initialize(cliflags.NewClientOptions()); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	containers, err := cli.Client().ContainerList(ctx, types.ContainerListOptions{Filters: filters.NewArgs(filters.Arg("label", label))})
+	if err != nil {
+		return err
+	}
+	timeout := stopGracePeriod / 2
+	for _, c := range containers {
+		if err := cli.Client().ContainerStop(ctx, c.ID, &timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
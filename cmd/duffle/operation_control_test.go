@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnabio/cnab-go/action"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/driver"
+	commanddriver "github.com/cnabio/cnab-go/driver/command"
+	"github.com/stretchr/testify/assert"
+)
+
+// funcDriver runs operations with a function, which is passed the number of the attempt, starting at 1.
+type funcDriver struct {
+	mu       sync.Mutex
+	attempts int
+	run      func(attempt int) error
+}
+
+func (d *funcDriver) Run(op *driver.Operation) (driver.OperationResult, error) {
+	d.mu.Lock()
+	d.attempts++
+	attempt := d.attempts
+	d.mu.Unlock()
+	return driver.OperationResult{}, d.run(attempt)
+}
+
+func (d *funcDriver) Handles(string) bool {
+	return true
+}
+
+func controlTestClaim(t *testing.T) *claim.Claim {
+	c, err := claim.New("controlled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Bundle = dryRunTestBundle()
+	return c
+}
+
+func TestControlRetries(t *testing.T) {
+	is := assert.New(t)
+	out := bytes.NewBuffer(nil)
+
+	d := &funcDriver{run: func(attempt int) error {
+		if attempt < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	}}
+	controlled, release := operationControl{retries: 2, backoff: time.Millisecond}.control(d, out)
+	defer release()
+
+	c := controlTestClaim(t)
+	is.NoError((&action.Install{Driver: controlled}).Run(c, nil))
+	is.Equal(3, d.attempts)
+	is.Equal(claim.StatusSuccess, c.Result.Status)
+	is.Contains(out.String(), "Attempt 1 of 3 failed: flaky. Retrying in 1ms...")
+	is.Contains(out.String(), "Attempt 2 of 3 failed: flaky. Retrying in 2ms...")
+
+	d = &funcDriver{run: func(int) error { return errors.New("broken") }}
+	controlled, release = operationControl{retries: 1, backoff: time.Millisecond}.control(d, out)
+	defer release()
+	c = controlTestClaim(t)
+	is.EqualError((&action.Install{Driver: controlled}).Run(c, nil), "broken")
+	is.Equal(2, d.attempts)
+	is.Equal(claim.StatusFailure, c.Result.Status)
+}
+
+func TestControlTimeout(t *testing.T) {
+	is := assert.New(t)
+
+	stop := make(chan struct{})
+	d := &funcDriver{run: func(int) error {
+		<-stop
+		return errors.New("container exit code: 137")
+	}}
+	controlled, release := operationControl{timeout: 10 * time.Millisecond, retries: 3}.control(d, bytes.NewBuffer(nil))
+	defer release()
+	controlled.(*controlledDriver).stop = func() error {
+		close(stop)
+		return nil
+	}
+
+	c := controlTestClaim(t)
+	err := (&action.Install{Driver: controlled}).Run(c, nil)
+	is.EqualError(err, "the operation timed out after 10ms")
+	recordCancellation(c, err)
+	is.Equal(claim.StatusFailure, c.Result.Status, "timeouts are failures")
+	is.Equal("the operation timed out after 10ms", c.Result.Message)
+	is.Equal(1, d.attempts, "cancelled operations are not retried")
+}
+
+func TestControlRefusesTimeoutForCommandDrivers(t *testing.T) {
+	is := assert.New(t)
+
+	d := &commanddriver.Driver{Name: "custom"}
+	is.EqualError(operationControl{timeout: time.Minute}.check(d), "the custom driver cannot stop operations, so --timeout cannot be used with it")
+	is.NoError(operationControl{}.check(d))
+	is.NoError(operationControl{timeout: time.Minute}.check(&driver.DebugDriver{}))
+}
@@ -0,0 +1,80 @@
+// +build !windows
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cnabio/cnab-go/action"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControlInterrupt(t *testing.T) {
+	is := assert.New(t)
+
+	defer func(d time.Duration) { stopGracePeriod = d }(stopGracePeriod)
+	stopGracePeriod = time.Hour
+
+	started := make(chan struct{})
+	d := &funcDriver{run: func(int) error {
+		close(started)
+		select {}
+	}}
+	out := bytes.NewBuffer(nil)
+	controlled, release := operationControl{}.control(flushingDriver{d}, out)
+	defer release()
+
+	go func() {
+		<-started
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+		// the driver never stops, so a second interrupt is needed
+		time.Sleep(50 * time.Millisecond)
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+	}()
+
+	c := controlTestClaim(t)
+	err := (&action.Install{Driver: controlled}).Run(c, nil)
+	is.EqualError(err, "the operation was cancelled (interrupt)")
+	recordCancellation(c, err)
+	is.Equal(statusCancelled, c.Result.Status)
+	is.Contains(out.String(), "Received interrupt, stopping the operation.")
+	is.Contains(out.String(), "Stopped waiting for the operation, which may still be running.")
+	_, abandoned := abandonedOperations.Load(c.Name)
+	is.True(abandoned, "the claim lock is kept while the operation may still be running")
+}
+
+func TestControlWaitsForUnstoppableDrivers(t *testing.T) {
+	is := assert.New(t)
+
+	defer func(d time.Duration) { stopGracePeriod = d }(stopGracePeriod)
+	stopGracePeriod = time.Millisecond
+
+	started := make(chan struct{})
+	d := &funcDriver{run: func(int) error {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	}}
+	out := bytes.NewBuffer(nil)
+	controlled, release := operationControl{}.control(d, out)
+	defer release()
+	controlled.(*controlledDriver).unstoppable = "custom"
+
+	go func() {
+		<-started
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+
+	c := controlTestClaim(t)
+	c.Name = "unstoppable"
+	err := (&action.Install{Driver: controlled}).Run(c, nil)
+	is.EqualError(err, "the operation was cancelled (terminated)")
+	is.Contains(out.String(), "The custom driver cannot stop the operation, waiting for it to finish.")
+	is.NotContains(out.String(), "Stopped waiting", "the grace period does not apply to operations that cannot be stopped")
+	_, abandoned := abandonedOperations.Load(c.Name)
+	is.False(abandoned)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/osutil"
+)
+
+const outputsDesc = `
+List the outputs of an installation, with their definitions, descriptions and values.
+
+The values are those produced by the last action run on the installation. The values of
+write-only outputs are masked unless '--show-secrets' is set.
+
+To write the outputs to files, pass '--output-dir' to 'duffle install', 'duffle upgrade' or
+'duffle run'. Each output is written to a file named after it, decoded if its definition
+has a base64 content encoding. Files of write-only outputs can only be read by their owner.
+`
+
+type outputsCmd struct {
+	name        string
+	showSecrets bool
+	output      outputFormat
+	out         io.Writer
+}
+
+// outputItem is an output of an installation as printed by duffle outputs.
+type outputItem struct {
+	Name        string      `json:"name"`
+	Definition  string      `json:"definition"`
+	Type        interface{} `json:"type,omitempty"`
+	Description string      `json:"description"`
+	// Set tells whether the last action produced the output.
+	Set   bool        `json:"set"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+func newOutputsCmd(w io.Writer) *cobra.Command {
+	outputs := &outputsCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "outputs NAME",
+		Short: "list the outputs of an installation",
+		Long:  outputsDesc,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputs.name = args[0]
+			return outputs.run()
+		},
+	}
+
+	cmd.Flags().BoolVar(&outputs.showSecrets, "show-secrets", false, "show the values of write-only outputs")
+	outputs.output.addFlag(cmd.Flags())
+
+	return cmd
+}
+
+func (o *outputsCmd) run() error {
+	if err := o.output.validate(); err != nil {
+		return err
+	}
+	c, err := claimStorage().Read(o.name)
+	if err == claim.ErrClaimNotFound {
+		return fmt.Errorf("Bundle installation '%s' not found", o.name)
+	} else if err != nil {
+		return err
+	}
+	if !o.showSecrets {
+		c = maskClaim(c)
+	}
+
+	items := []outputItem{}
+	if c.Bundle != nil {
+		for _, name := range outputNames(c.Bundle) {
+			output := c.Bundle.Outputs[name]
+			item := outputItem{Name: name, Definition: output.Definition, Description: output.Description}
+			if def, ok := c.Bundle.Definitions[output.Definition]; ok {
+				item.Type = def.Type
+				if item.Description == "" {
+					item.Description = def.Description
+				}
+			}
+			item.Value, item.Set = c.Outputs[name]
+			items = append(items, item)
+		}
+	}
+
+	return o.output.print(o.out, items, func() error {
+		if len(items) == 0 {
+			fmt.Fprintf(o.out, "The bundle of %s has no outputs\n", o.name)
+			return nil
+		}
+		table := uitable.New()
+		table.MaxColWidth = 50
+		table.Wrap = true
+		table.AddRow("NAME", "DEFINITION", "TYPE", "DESCRIPTION", "VALUE")
+		for _, item := range items {
+			val := item.Value
+			if !item.Set {
+				val = "(not set)"
+			}
+			table.AddRow(item.Name, item.Definition, item.Type, item.Description, val)
+		}
+		fmt.Fprintln(o.out, table)
+		return nil
+	})
+}
+
+// outputNames returns the names of the outputs of bun, sorted.
+func outputNames(bun *bundle.Bundle) []string {
+	names := make([]string, 0, len(bun.Outputs))
+	for name := range bun.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeOutputs writes each output of the installation to a file named after it in dir. Outputs with a base64 content
+// encoding are decoded, and the files of write-only outputs are only readable by their owner.
+func writeOutputs(w io.Writer, dir string, c claim.Claim) error {
+	if c.Bundle == nil || len(c.Outputs) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	written := 0
+	for _, name := range outputNames(c.Bundle) {
+		val, ok := c.Outputs[name]
+		if !ok {
+			continue
+		}
+		if filepath.Base(name) != name || name == "." || name == ".." {
+			return fmt.Errorf("cannot write output %q to a file: the name is not a valid file name", name)
+		}
+
+		content := []byte(fmt.Sprint(val))
+		mode := os.FileMode(0644)
+		if def, ok := c.Bundle.Definitions[c.Bundle.Outputs[name].Definition]; ok {
+			if def.ContentEncoding == "base64" {
+				decoded, err := base64.StdEncoding.DecodeString(string(content))
+				if err != nil {
+					return fmt.Errorf("cannot decode output %q: %v", name, err)
+				}
+				content = decoded
+			}
+			if isWriteOnly(def) {
+				mode = 0600
+			}
+		}
+		if err := osutil.AtomicWriteFile(filepath.Join(dir, name), content, mode); err != nil {
+			return err
+		}
+		written++
+	}
+	fmt.Fprintf(w, "Wrote %d outputs to %s\n", written, dir)
+	return nil
+}
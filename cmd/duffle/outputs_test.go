@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/stretchr/testify/assert"
+)
+
+// outputsDriver puts a driver named "outputs", which writes the given outputs, on the PATH, and returns a function
+// that restores the PATH.
+func outputsDriver(t *testing.T, dir string, outputs map[string]string) func() {
+	if runtime.GOOS == "windows" {
+		t.Skip("the outputs driver is a shell script")
+	}
+	script := "#!/bin/sh\nif [ \"$1\" = --handles ]; then echo docker; exit 0; fi\nmkdir -p \"$CNAB_OUTPUT_DIR/cnab/app/outputs\"\n"
+	for name, val := range outputs {
+		script += fmt.Sprintf("printf '%%s' '%s' > \"$CNAB_OUTPUT_DIR/cnab/app/outputs/%s\"\n", val, name)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "cnab-outputs"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return func() { os.Setenv("PATH", path) }
+}
+
+func TestOutputs(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	cert := base64.StdEncoding.EncodeToString([]byte("-----BEGIN CERTIFICATE-----\n"))
+	defer outputsDriver(t, testHome.String(), map[string]string{"host": "db.example.com", "password": "s3cret", "cert": cert})()
+
+	writeOnly := true
+	bun := dryRunTestBundle()
+	bun.Definitions["password"] = &definition.Schema{Type: "string", WriteOnly: &writeOnly}
+	bun.Definitions["cert"] = &definition.Schema{Type: "string", ContentEncoding: "base64", Description: "the CA certificate"}
+	bun.Outputs = map[string]bundle.Output{
+		"host":     {Definition: "host", Path: "/cnab/app/outputs/host", Description: "the host of the database"},
+		"password": {Definition: "password", Path: "/cnab/app/outputs/password"},
+		"cert":     {Definition: "cert", Path: "/cnab/app/outputs/cert"},
+	}
+	data, err := json.Marshal(bun)
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	outDir := filepath.Join(testHome.String(), "outputs")
+	out := bytes.NewBuffer(nil)
+	install := &installCmd{bundle: bundleFile, bundleIsFile: true, name: "db", home: testHome, out: out, driver: "outputs", outputDir: outDir}
+	is.NoError(install.run())
+	is.Contains(out.String(), "Wrote 3 outputs to "+outDir)
+
+	for name, expected := range map[string]struct {
+		content string
+		mode    os.FileMode
+	}{
+		"host":     {"db.example.com", 0644},
+		"password": {"s3cret", 0600},
+		"cert":     {"-----BEGIN CERTIFICATE-----\n", 0644},
+	} {
+		path := filepath.Join(outDir, name)
+		content, err := ioutil.ReadFile(path)
+		is.NoError(err)
+		is.Equal(expected.content, string(content), name)
+		info, err := os.Stat(path)
+		is.NoError(err)
+		is.Equal(expected.mode, info.Mode().Perm(), name)
+	}
+
+	out.Reset()
+	is.NoError((&outputsCmd{name: "db", out: out}).run())
+	is.Regexp(`cert\s+cert\s+string\s+the CA certificate\s+`+cert, out.String())
+	is.Regexp(`host\s+host\s+string\s+the host of the database\s+db.example.com`, out.String())
+	is.Regexp(`password\s+password\s+string\s+\*+`, out.String())
+	is.NotContains(out.String(), "s3cret")
+
+	out.Reset()
+	is.NoError((&outputsCmd{name: "db", out: out, showSecrets: true}).run())
+	is.Contains(out.String(), "s3cret")
+
+	is.EqualError((&outputsCmd{name: "nope", out: out}).run(), "Bundle installation 'nope' not found")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/parameters"
+)
+
+const parameterEditDesc = `
+Open an editor for editing the named parameter set.
+
+Upon saving and exiting the editor, this will write the updated parameter set.
+
+This uses the values of $EDITOR or $VISUAL to figure out which editor to use. If none is found,
+this will default to 'vi' on UNIX-like systems and Notepad on Windows.
+`
+
+type parameterEditCmd struct {
+	name string
+	home home.Home
+	out  io.Writer
+}
+
+func newParameterEditCmd(w io.Writer) *cobra.Command {
+	edit := &parameterEditCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "edit [NAME]",
+		Short: "edit an existing parameter set",
+		Long:  parameterEditDesc,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			edit.home = home.Home(homePath())
+			edit.name = args[0]
+			return edit.run()
+		},
+	}
+
+	return cmd
+}
+
+func (p *parameterEditCmd) run() error {
+	params, err := findParameterSet(p.home.Parameters(), p.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	strdata := `# Parameter fields:
+# - name: NAME OF PARAMETER
+#   source:
+#     value: "A literal value"
+#     env: ENV_VAR_NAME  # environment variable containing the value
+#     path: /some/path   # path to a file containing the value
+#     command: cmd args  # command printing the value
+` + string(data)
+
+	prompt := &survey.Editor{
+		Message: "Edit your parameters, then save and exit.",
+		Default: strdata,
+		// This shows the text in the editor.
+		AppendDefault: true,
+		// This hides the text from the prompt.
+		HideDefault: true,
+	}
+
+	var dest string
+	if err := survey.AskOne(prompt, &dest, nil); err != nil {
+		return err
+	}
+
+	// Validate that this works.
+	newparams := &parameters.ParameterSet{}
+	if err := yaml.Unmarshal([]byte(dest), newparams); err != nil {
+		return fmt.Errorf("new parameters are malformed: %s", err)
+	}
+
+	destpath := filepath.Join(p.home.Parameters(), p.name+".yaml")
+	return ioutil.WriteFile(destpath, []byte(dest), 0600)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/spf13/cobra"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/parameters"
+)
+
+const parameterGenerateHelp = `Generate parameters from a CNAB bundle
+
+This reads a bundle.json file's parameters and generates a stub parameterset.
+The given name becomes the name of the parameterset.
+
+If a bundle is given, the bundle may be fetched (unless there is a cached copy),
+and will then be examined. If the '-f' flag is specified, though, it will read the
+bundle.json supplied.
+
+Unless prompted for, the generated parameters are initialized to their default values,
+or to stub values if they have no default, and should be edited to reflect the true values.
+`
+
+func newParameterGenerateCmd(out io.Writer) *cobra.Command {
+	bundleFile := ""
+	var (
+		dryRun   bool
+		noPrompt bool
+	)
+	cmd := &cobra.Command{
+		Use:     "generate NAME [BUNDLE]",
+		Aliases: []string{"gen"},
+		Short:   "generate a parameterset from a bundle",
+		Long:    parameterGenerateHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bf, err := getBundleFileFromParametersArg(args, bundleFile)
+			if err != nil {
+				return err
+			}
+			psName := args[0]
+
+			bun, err := loadBundle(bf)
+			if err != nil {
+				return err
+			}
+
+			generator := genParameterSurvey
+			if noPrompt {
+				generator = genDefaultParameters
+			}
+
+			params, err := genParameterSet(psName, bun, generator)
+			if err != nil {
+				return err
+			}
+			data, err := yaml.Marshal(params)
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				fmt.Fprintf(out, "%v", string(data))
+				return nil
+			}
+
+			dest := filepath.Join(home.Home(homePath()).Parameters(), psName+".yaml")
+			return ioutil.WriteFile(dest, data, 0600)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&bundleFile, "file", "f", "", "path to bundle.json")
+	f.BoolVar(&dryRun, "dry-run", false, "show prompts and result, but don't create parameter set")
+	f.BoolVarP(&noPrompt, "no-prompt", "q", false, "do not prompt for input, but generate a stub parameterset")
+
+	return cmd
+}
+
+// parameterGenerator generates the strategy for a parameter. The default is the default value of the parameter,
+// formatted as a string, or empty if it has none.
+type parameterGenerator func(name, def string) (parameters.ParameterStrategy, error)
+
+func genParameterSet(name string, bun *bundle.Bundle, fn parameterGenerator) (parameters.ParameterSet, error) {
+	ps := parameters.ParameterSet{
+		Name:       name,
+		Parameters: []parameters.ParameterStrategy{},
+	}
+
+	if strings.ContainsAny(name, "./\\") {
+		return ps, fmt.Errorf("parameterset name '%s' cannot contain the following characters: './\\'", name)
+	}
+
+	var parameterNames []string
+	for name := range bun.Parameters {
+		parameterNames = append(parameterNames, name)
+	}
+
+	sort.Strings(parameterNames)
+
+	for _, name := range parameterNames {
+		def := ""
+		if schema, ok := bun.Definitions[bun.Parameters[name].Definition]; ok && schema.Default != nil {
+			def = fmt.Sprintf("%v", schema.Default)
+		}
+		p, err := fn(name, def)
+		if err != nil {
+			return ps, err
+		}
+		ps.Parameters = append(ps.Parameters, p)
+	}
+
+	return ps, nil
+}
+
+func genDefaultParameters(name, def string) (parameters.ParameterStrategy, error) {
+	if def == "" {
+		def = "EMPTY"
+	}
+	return parameters.ParameterStrategy{
+		Name:   name,
+		Source: parameters.Source{Value: def},
+	}, nil
+}
+
+func genParameterSurvey(name, def string) (parameters.ParameterStrategy, error) {
+	questions := []*survey.Question{
+		{
+			Name: "source",
+			Prompt: &survey.Select{
+				Message: fmt.Sprintf("Choose a source for %q", name),
+				Options: []string{questionValue, questionEnvVar, questionPath, questionCommand},
+				Default: questionValue,
+			},
+		},
+		{
+			Name: "value",
+			Prompt: &survey.Input{
+				Message: fmt.Sprintf("Enter a value for %q", name),
+				Default: def,
+			},
+		},
+	}
+	p := parameters.ParameterStrategy{Name: name}
+	answers := &credentialAnswers{}
+
+	if err := survey.Ask(questions, answers); err != nil {
+		return p, err
+	}
+
+	switch answers.Source {
+	case questionValue:
+		p.Source.Value = answers.Value
+	case questionEnvVar:
+		p.Source.EnvVar = answers.Value
+	case questionPath:
+		p.Source.Path = answers.Value
+	case questionCommand:
+		p.Source.Command = answers.Value
+	}
+	return p, nil
+}
+
+func getBundleFileFromParametersArg(args []string, bundleFile string) (string, error) {
+	switch {
+	case len(args) < 1:
+		return "", errors.New("This command requires at least one argument: NAME (name for the parameterset). It also requires a BUNDLE (CNAB bundle name) or file (using -f)\nValid inputs:\n\t$ duffle parameters generate NAME BUNDLE\n\t$ duffle parameters generate NAME -f path-to-bundle.json")
+	case len(args) == 2 && bundleFile != "":
+		return "", errors.New("please use either -f or specify a BUNDLE, but not both")
+	case len(args) < 2 && bundleFile == "":
+		return "", errors.New("required arguments are NAME (name for the parameterset) and BUNDLE (CNAB bundle name) or file")
+	case len(args) == 2:
+		return getBundleFilepath(args[1], homePath())
+	}
+	return bundleFile, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gosuri/uitable"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/parameters"
+)
+
+type parameterListCmd struct {
+	out    io.Writer
+	home   home.Home
+	short  bool
+	output outputFormat
+}
+
+func newParameterListCmd(w io.Writer) *cobra.Command {
+	list := &parameterListCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "list parameter sets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list.home = home.Home(homePath())
+			return list.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&list.short, "short", "s", false, "output shorter listing format")
+	list.output.addFlag(f)
+
+	return cmd
+}
+
+func (ls *parameterListCmd) run() error {
+	if err := ls.output.validateShort(ls.short); err != nil {
+		return err
+	}
+	params := findParameterSets(ls.home.Parameters())
+
+	if ls.short {
+		for _, item := range params {
+			fmt.Fprintln(ls.out, item.name)
+		}
+		return nil
+	}
+
+	items := make([]setItem, 0, len(params))
+	for _, param := range params {
+		items = append(items, setItem{Name: param.name, Path: param.path})
+	}
+	return ls.output.print(ls.out, items, func() error {
+		table := uitable.New()
+		table.MaxColWidth = 80
+		table.Wrap = true
+
+		table.AddRow("NAME", "PATH")
+		for _, item := range items {
+			table.AddRow(item.Name, item.Path)
+		}
+
+		fmt.Fprintln(ls.out, table)
+		return nil
+	})
+}
+
+type paramListItem struct {
+	name string
+	path string
+}
+
+func findParameterSets(dir string) []paramListItem {
+	params := []paramListItem{}
+
+	log.Debugf("Traversing parameters directory (%s) for parameter sets", dir)
+
+	filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !f.IsDir() {
+			log.Debugf("Loading parameter set from %s", path)
+			pset, err := parameters.Load(path)
+			if err != nil {
+				log.Debugf("Unable to load parameter set from %s:\n%s", path, err)
+				return nil
+			}
+
+			log.Debugf("Successfully loaded parameter set %s from %s", pset.Name, path)
+			params = append(params, paramListItem{name: pset.Name, path: path})
+		}
+		return nil
+	})
+
+	return params
+}
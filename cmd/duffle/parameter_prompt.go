@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+)
+
+// parameterPrompter asks the user for the value of the named parameter, defined by schema.
+type parameterPrompter func(name string, schema *definition.Schema) (interface{}, error)
+
+// promptForMissingParameters prompts for the value of each required parameter of the bundle that has no value in
+// vals yet, and adds the answers to vals.
+func promptForMissingParameters(bun *bundle.Bundle, vals map[string]interface{}, prompt parameterPrompter) error {
+	names := make([]string, 0, len(bun.Parameters))
+	for name := range bun.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		param := bun.Parameters[name]
+		if _, ok := vals[name]; ok || !param.Required {
+			continue
+		}
+		schema, ok := bun.Definitions[param.Definition]
+		if !ok {
+			return fmt.Errorf("definition %q of parameter %q is not present in bundle", param.Definition, name)
+		}
+		val, err := prompt(name, schema)
+		if err != nil {
+			return fmt.Errorf("could not read a value for parameter %q: %v", name, err)
+		}
+		vals[name] = val
+	}
+	return nil
+}
+
+// surveyParameterPrompt prompts for a parameter value on the terminal.
+//
+// Parameters with an enum are chosen from a list, booleans are confirmed, and write-only parameters are read without
+// echoing them. All other answers are validated against the parameter's definition.
+func surveyParameterPrompt(name string, schema *definition.Schema) (interface{}, error) {
+	message := fmt.Sprintf("Enter a value for %q%s", name, parameterHint(schema))
+
+	if len(schema.Enum) > 0 {
+		options := make([]string, len(schema.Enum))
+		for i, e := range schema.Enum {
+			options[i] = fmt.Sprintf("%v", e)
+		}
+		prompt := &survey.Select{
+			Message: fmt.Sprintf("Choose a value for %q", name),
+			Options: options,
+			Help:    schema.Description,
+		}
+		if schema.Default != nil {
+			prompt.Default = fmt.Sprintf("%v", schema.Default)
+		}
+		var answer string
+		if err := survey.AskOne(prompt, &answer, nil); err != nil {
+			return nil, err
+		}
+		for i, o := range options {
+			if o == answer {
+				return schema.Enum[i], nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not one of the allowed values", answer)
+	}
+
+	if dataType, ok, _ := schema.GetType(); ok && dataType == "boolean" {
+		prompt := &survey.Confirm{
+			Message: message,
+			Help:    schema.Description,
+		}
+		if def, ok := schema.Default.(bool); ok {
+			prompt.Default = def
+		}
+		var answer bool
+		err := survey.AskOne(prompt, &answer, nil)
+		return answer, err
+	}
+
+	validate := func(ans interface{}) error {
+		_, err := parseParameterAnswer(schema, fmt.Sprintf("%v", ans))
+		return err
+	}
+	var prompt survey.Prompt
+	if isWriteOnly(schema) {
+		prompt = &survey.Password{
+			Message: message,
+			Help:    schema.Description,
+		}
+	} else {
+		input := &survey.Input{
+			Message: message,
+			Help:    schema.Description,
+		}
+		if schema.Default != nil {
+			input.Default = fmt.Sprintf("%v", schema.Default)
+		}
+		prompt = input
+	}
+	var answer string
+	if err := survey.AskOne(prompt, &answer, validate); err != nil {
+		return nil, err
+	}
+	return parseParameterAnswer(schema, answer)
+}
+
+// parseParameterAnswer converts an answer to the type of the parameter and validates it against the definition.
+func parseParameterAnswer(schema *definition.Schema, answer string) (interface{}, error) {
+	var (
+		val interface{}
+		err error
+	)
+	if dataType, ok, _ := schema.GetType(); ok && dataType == "number" {
+		val, err = strconv.ParseFloat(answer, 64)
+	} else {
+		val, err = schema.ConvertValue(answer)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid value: %v", answer, err)
+	}
+
+	valErrs, err := schema.Validate(val)
+	if err != nil {
+		return nil, err
+	}
+	if len(valErrs) > 0 {
+		return nil, errors.New(valErrs[0].Error)
+	}
+	return val, nil
+}
+
+// parameterHint describes the constraints on a parameter value.
+func parameterHint(schema *definition.Schema) string {
+	hints := []string{}
+	if dataType, ok, _ := schema.GetType(); ok {
+		hints = append(hints, dataType)
+	}
+	if schema.Minimum != nil {
+		hints = append(hints, fmt.Sprintf("minimum %d", *schema.Minimum))
+	}
+	if schema.Maximum != nil {
+		hints = append(hints, fmt.Sprintf("maximum %d", *schema.Maximum))
+	}
+	if len(hints) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(hints, ", ") + ")"
+}
+
+// isWriteOnly reports whether the definition marks values as sensitive.
+func isWriteOnly(schema *definition.Schema) bool {
+	return schema.WriteOnly != nil && *schema.WriteOnly
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(i int) *int { return &i }
+
+func promptTestBundle() *bundle.Bundle {
+	writeOnly := true
+	return &bundle.Bundle{
+		SchemaVersion: "v1.0.0-WD",
+		Name:          "prompt",
+		Version:       "0.1.0",
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "prompt/cnab:0.1.0", ImageType: "docker"}},
+		},
+		Definitions: definition.Definitions{
+			"replicas": {Type: "integer", Minimum: intPtr(1), Maximum: intPtr(5)},
+			"size":     {Type: "string", Enum: []interface{}{"small", "large"}},
+			"password": {Type: "string", WriteOnly: &writeOnly},
+			"optional": {Type: "string", Default: "default"},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"replicas": {Definition: "replicas", Required: true},
+			"size":     {Definition: "size", Required: true},
+			"password": {Definition: "password", Required: true},
+			"optional": {Definition: "optional"},
+		},
+	}
+}
+
+func TestPromptForMissingParameters(t *testing.T) {
+	is := assert.New(t)
+	bun := promptTestBundle()
+
+	asked := []string{}
+	answers := map[string]interface{}{"replicas": 3, "password": "hunter2"}
+	prompt := func(name string, schema *definition.Schema) (interface{}, error) {
+		asked = append(asked, name)
+		return answers[name], nil
+	}
+
+	vals := map[string]interface{}{"size": "small"}
+	is.NoError(promptForMissingParameters(bun, vals, prompt))
+	is.Equal([]string{"password", "replicas"}, asked, "only required parameters without a value are prompted for")
+	is.Equal(map[string]interface{}{"size": "small", "replicas": 3, "password": "hunter2"}, vals)
+}
+
+func TestParseParameterAnswer(t *testing.T) {
+	is := assert.New(t)
+	defs := promptTestBundle().Definitions
+
+	v, err := parseParameterAnswer(defs["replicas"], "3")
+	is.NoError(err)
+	is.Equal(3, v)
+
+	_, err = parseParameterAnswer(defs["replicas"], "three")
+	is.Error(err)
+	_, err = parseParameterAnswer(defs["replicas"], "6")
+	is.Error(err, "maximum is enforced")
+	_, err = parseParameterAnswer(defs["replicas"], "0")
+	is.Error(err, "minimum is enforced")
+	_, err = parseParameterAnswer(defs["size"], "medium")
+	is.Error(err, "enum is enforced")
+
+	v, err = parseParameterAnswer(&definition.Schema{Type: "number"}, "1.5")
+	is.NoError(err)
+	is.Equal(1.5, v)
+}
+
+func TestParameterHint(t *testing.T) {
+	defs := promptTestBundle().Definitions
+	assert.Equal(t, " (integer, minimum 1, maximum 5)", parameterHint(defs["replicas"]))
+	assert.Equal(t, "", parameterHint(&definition.Schema{}))
+}
+
+func TestInstallInteractive(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	data, err := json.Marshal(promptTestBundle())
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	install := &installCmd{
+		bundle:       bundleFile,
+		bundleIsFile: true,
+		name:         "prompted",
+		home:         testHome,
+		out:          ioutil.Discard,
+		driver:       "debug",
+		setParams:    []string{"replicas=2"},
+	}
+	err = install.run()
+	is.Error(err)
+	is.Contains(err.Error(), "is required")
+
+	install.interactive = true
+	install.prompt = func(name string, schema *definition.Schema) (interface{}, error) {
+		return map[string]interface{}{"size": "large", "password": "hunter2"}[name], nil
+	}
+	is.NoError(install.run())
+
+	c, err := claimStorage().Read("prompted")
+	is.NoError(err)
+	is.Equal(map[string]interface{}{
+		"replicas": float64(2),
+		"size":     "large",
+		"password": "hunter2",
+		"optional": "default",
+	}, c.Parameters)
+}
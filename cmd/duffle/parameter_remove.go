@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+)
+
+type parameterRemoveCmd struct {
+	names []string
+	home  home.Home
+	out   io.Writer
+}
+
+func newParameterRemoveCmd(w io.Writer) *cobra.Command {
+	rm := &parameterRemoveCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:     "remove [NAME]",
+		Short:   "remove one or more parameter sets",
+		Aliases: []string{"rm"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("This command requires at least 1 argument: name of parameter set")
+			}
+			rm.names = args
+			rm.home = home.Home(homePath())
+			return rm.run()
+		},
+	}
+	return cmd
+}
+
+func (rm *parameterRemoveCmd) run() error {
+	var removeErrors []string
+	var notFound []string
+
+	pathMap := map[string]string{}
+	for _, param := range findParameterSets(rm.home.Parameters()) {
+		pathMap[param.name] = param.path
+	}
+
+	for _, name := range rm.names {
+		if path, ok := pathMap[name]; ok {
+			if err := os.Remove(path); err != nil {
+				removeErrors = append(removeErrors, fmt.Sprintf("Failed to remove parameter set %s: %v", name, err))
+			} else {
+				fmt.Fprintf(rm.out, "Removed parameter set: %s\n", name)
+			}
+		} else {
+			notFound = append(notFound, name)
+		}
+	}
+
+	if len(notFound) > 0 {
+		notFoundError := fmt.Sprintf("Unable to find parameter set(s): %v", strings.Join(notFound, ", "))
+		removeErrors = append(removeErrors, notFoundError)
+	}
+
+	if len(removeErrors) > 0 {
+		return errors.New(strings.Join(removeErrors, "\n"))
+	}
+
+	return nil
+}
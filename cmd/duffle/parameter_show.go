@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/parameters"
+)
+
+const parameterShowDesc = `
+This command will fetch the parameter set with the given name and prints the contents of the file.
+`
+
+type parameterShowCmd struct {
+	name   string
+	output outputFormat
+	home   home.Home
+	out    io.Writer
+}
+
+func newParameterShowCmd(w io.Writer) *cobra.Command {
+	show := &parameterShowCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "show [NAME]",
+		Short: "show parameter set",
+		Long:  parameterShowDesc,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			show.home = home.Home(homePath())
+			show.name = args[0]
+			return show.run()
+		},
+	}
+	show.output.addShowFlag(cmd.Flags())
+	return cmd
+}
+
+func (sh *parameterShowCmd) run() error {
+	if err := sh.output.validate(); err != nil {
+		return err
+	}
+	ps, err := findParameterSet(sh.home.Parameters(), sh.name)
+	if err != nil {
+		return err
+	}
+	return sh.output.print(sh.out, ps, func() error {
+		b, err := yaml.Marshal(ps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(sh.out, string(b))
+		return nil
+	})
+}
+
+func findParameterSet(dir, name string) (*parameters.ParameterSet, error) {
+	return parameters.Load(filepath.Join(dir, fmt.Sprintf("%s.yaml", name)))
+}
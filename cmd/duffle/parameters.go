@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+const parameterDesc = `
+Manages parameter sets.
+
+A parameter set (parameterset) is a named collection of parameter values. It assigns each
+parameter a place where its value can be found. Parameter sets are used to supply parameters
+during operations such as 'duffle install' or 'duffle upgrade'.
+
+Various commands, such as 'duffle install', provide the '--parameters'/'-p' flag. If its value
+is not the path to a parameters file, it is used as the name of a parameter set.
+
+Duffle provides local parameter set storage in the Duffle configuration directory. On a
+UNIX-like system, these are stored in '$HOME/.duffle/parameters'. But parameter sets are
+just text files that map parameter names to local sources.
+
+A parameter set can retrieve parameter values from the following four sources:
+
+	- a hard-coded value
+	- an environment variable in the local environment
+	- a file on the local file system
+	- a command executed on the local system
+
+Values are converted to the type that the bundle defines for the parameter.
+`
+
+func newParametersCmd(w io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "parameters",
+		Short:   "manage parameter sets",
+		Long:    parameterDesc,
+		Aliases: []string{"params", "parameter", "param"},
+	}
+
+	cmd.AddCommand(
+		newParameterListCmd(w),
+		newParameterRemoveCmd(w),
+		newParameterShowCmd(w),
+		newParameterGenerateCmd(w),
+		newParameterEditCmd(w),
+	)
+
+	return cmd
+}
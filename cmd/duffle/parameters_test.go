@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/stretchr/testify/assert"
+)
+
+func parametersTestBundle() *bundle.Bundle {
+	return &bundle.Bundle{
+		Name: "params",
+		Definitions: definition.Definitions{
+			"string":  {Type: "string"},
+			"integer": {Type: "integer", Default: 1},
+			"boolean": {Type: "boolean"},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"region":   {Definition: "string"},
+			"replicas": {Definition: "integer"},
+			"debug":    {Definition: "boolean"},
+		},
+	}
+}
+
+func TestParseValuesFormats(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "duffle-params")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"params.json": `{"region": "westus", "replicas": 3, "debug": true}`,
+		"params.yaml": "region: westus\nreplicas: 3\ndebug: true\n",
+		"params.yml":  "region: westus\nreplicas: 3\ndebug: true\n",
+		"params.toml": "region = \"westus\"\nreplicas = 3\ndebug = true\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		is.NoError(ioutil.WriteFile(path, []byte(content), 0644))
+
+		vals, err := calculateParamValues(parametersTestBundle(), path, nil, nil)
+		is.NoError(err, name)
+		is.Equal(map[string]interface{}{"region": "westus", "replicas": 3, "debug": true}, vals, name)
+	}
+
+	bad := filepath.Join(dir, "bad.toml")
+	is.NoError(ioutil.WriteFile(bad, []byte("region = "), 0644))
+	_, err = parseValues(bad)
+	is.Error(err)
+	is.Contains(err.Error(), "cannot parse parameters from "+bad)
+}
+
+func TestCalculateParamValuesFromParameterSet(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	pset := `name: prod
+parameters:
+  - name: region
+    source:
+      value: eastus
+  - name: debug
+    source:
+      env: DUFFLE_TEST_DEBUG
+  - name: unrelated
+    source:
+      value: ignored
+`
+	is.NoError(ioutil.WriteFile(filepath.Join(testHome.Parameters(), "prod.yaml"), []byte(pset), 0600))
+	os.Setenv("DUFFLE_TEST_DEBUG", "true")
+	defer os.Unsetenv("DUFFLE_TEST_DEBUG")
+
+	vals, err := calculateParamValues(parametersTestBundle(), "prod", []string{"region=centralus"}, nil)
+	is.NoError(err)
+	is.Equal(map[string]interface{}{"region": "centralus", "replicas": 1, "debug": true}, vals)
+
+	_, err = calculateParamValues(parametersTestBundle(), "nope", nil, nil)
+	is.Error(err)
+	is.Contains(err.Error(), `"nope" is neither a parameters file nor a parameter set`)
+
+	bad := `name: bad
+parameters:
+  - name: replicas
+    source:
+      value: many
+`
+	is.NoError(ioutil.WriteFile(filepath.Join(testHome.Parameters(), "bad.yaml"), []byte(bad), 0600))
+	_, err = calculateParamValues(parametersTestBundle(), "bad", nil, nil)
+	is.Error(err)
+	is.Contains(err.Error(), "cannot use many as value of replicas")
+}
+
+func TestGenParameterSet(t *testing.T) {
+	is := assert.New(t)
+	ps, err := genParameterSet("zed", parametersTestBundle(), genDefaultParameters)
+	is.NoError(err)
+	is.Equal("zed", ps.Name)
+	is.Len(ps.Parameters, 3)
+
+	got := map[string]string{}
+	order := []string{}
+	for _, p := range ps.Parameters {
+		got[p.Name] = p.Source.Value
+		order = append(order, p.Name)
+	}
+	is.Equal([]string{"debug", "region", "replicas"}, order)
+	is.Equal(map[string]string{"debug": "EMPTY", "region": "EMPTY", "replicas": "1"}, got)
+
+	_, err = genParameterSet("all.of.the/above\\", parametersTestBundle(), genDefaultParameters)
+	is.Error(err)
+}
+
+func TestParameterListShowRemove(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	for _, name := range []string{"dev", "prod"} {
+		pset := "name: " + name + "\nparameters:\n  - name: region\n    source:\n      value: westus\n"
+		is.NoError(ioutil.WriteFile(filepath.Join(testHome.Parameters(), name+".yaml"), []byte(pset), 0600))
+	}
+
+	out := bytes.NewBuffer(nil)
+	list := &parameterListCmd{home: testHome, out: out, short: true}
+	is.NoError(list.run())
+	is.Equal("dev\nprod\n", out.String())
+
+	out.Reset()
+	show := &parameterShowCmd{home: testHome, out: out, name: "prod"}
+	is.NoError(show.run())
+	is.Equal("name: prod\nparameters:\n- name: region\n  source:\n    value: westus\n", out.String())
+
+	out.Reset()
+	rm := &parameterRemoveCmd{home: testHome, out: out, names: []string{"dev", "missing"}}
+	is.EqualError(rm.run(), "Unable to find parameter set(s): missing")
+	is.Contains(out.String(), "Removed parameter set: dev")
+
+	out.Reset()
+	is.NoError(list.run())
+	is.Equal("prod\n", out.String())
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// The formats of the output of the commands that print data, set with -o.
+const (
+	formatTable    = "table"
+	formatJSON     = "json"
+	formatYAML     = "yaml"
+	jsonPathPrefix = "jsonpath="
+)
+
+// outputFormat is the format in which a command prints its data.
+//
+// The data is printed as JSON, YAML or a JSONPath template applied to its JSON form. The field names of the JSON form
+// are part of the interface of Duffle: they are only added to, never renamed.
+type outputFormat string
+
+func (o *outputFormat) addFlag(f *pflag.FlagSet) {
+	f.StringVarP((*string)(o), "output", "o", formatTable, "Output format: table, json, yaml, or jsonpath=TEMPLATE, as in jsonpath='{[*].name}'")
+}
+
+// addShowFlag adds the flag to a command that shows a single document, which is printed in its usual form by default.
+func (o *outputFormat) addShowFlag(f *pflag.FlagSet) {
+	f.StringVarP((*string)(o), "output", "o", "", "Output format: json, yaml, or jsonpath=TEMPLATE, as in jsonpath='{.name}'")
+}
+
+// table reports whether the data is printed as a table for people to read.
+func (o outputFormat) table() bool {
+	return o == "" || o == formatTable
+}
+
+// validate checks that the format is known, so that commands can fail before doing anything.
+func (o outputFormat) validate() error {
+	switch {
+	case o.table(), o == formatJSON, o == formatYAML:
+		return nil
+	case strings.HasPrefix(string(o), jsonPathPrefix):
+		_, err := o.jsonPath()
+		return err
+	default:
+		return fmt.Errorf("unknown output format %q: use table, json, yaml or jsonpath=TEMPLATE", string(o))
+	}
+}
+
+// validateShort checks that the format is not set along with --short, which prints a table of names.
+func (o outputFormat) validateShort(short bool) error {
+	if short && !o.table() {
+		return errors.New("--short and --output cannot be used together")
+	}
+	return o.validate()
+}
+
+// print writes v to w in the format, or calls table to print it as a table.
+func (o outputFormat) print(w io.Writer, v interface{}, table func() error) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+	switch {
+	case o.table():
+		return table()
+	case o == formatJSON:
+		return displayAsJSON(w, v)
+	case o == formatYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	jp, _ := o.jsonPath()
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// apply the template to the JSON form of v, so that it uses the same field names
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := jp.Execute(buf, doc); err != nil {
+		return fmt.Errorf("cannot apply the JSONPath template: %v", err)
+	}
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// jsonPath parses the template of a jsonpath format. The braces around a template with a single expression may be
+// left out, as in jsonpath=.name.
+func (o outputFormat) jsonPath() (*jsonpath.JSONPath, error) {
+	tmpl := strings.TrimPrefix(string(o), jsonPathPrefix)
+	if tmpl == "" {
+		return nil, errors.New("the jsonpath output format needs a template, as in jsonpath='{[*].name}'")
+	}
+	if !strings.Contains(tmpl, "{") {
+		tmpl = "{" + tmpl + "}"
+	}
+	jp := jsonpath.New("output")
+	if err := jp.Parse(tmpl); err != nil {
+		return nil, fmt.Errorf("invalid JSONPath template %q: %v", tmpl, err)
+	}
+	return jp, nil
+}
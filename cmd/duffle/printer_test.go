@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputFormat(t *testing.T) {
+	type item struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	items := []item{{"wordpress", "0.1.0"}, {"mysql", "1.2.0"}}
+	table := func() error { return errors.New("table") }
+
+	for _, tc := range []struct {
+		format   outputFormat
+		expected string
+	}{
+		{formatJSON, "[\n  {\n    \"name\": \"wordpress\",\n    \"version\": \"0.1.0\"\n  },\n  {\n    \"name\": \"mysql\",\n    \"version\": \"1.2.0\"\n  }\n]\n"},
+		{formatYAML, "- name: wordpress\n  version: 0.1.0\n- name: mysql\n  version: 1.2.0\n"},
+		{"jsonpath={[*].name}", "wordpress mysql\n"},
+		{"jsonpath=[1].version", "1.2.0\n"},
+		{`jsonpath={range [*]}{.name}={.version}{"\n"}{end}`, "wordpress=0.1.0\nmysql=1.2.0\n"},
+	} {
+		out := bytes.NewBuffer(nil)
+		if err := tc.format.print(out, items, table); err != nil {
+			t.Errorf("%s: %v", tc.format, err)
+			continue
+		}
+		if out.String() != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.format, tc.expected, out.String())
+		}
+	}
+
+	is := assert.New(t)
+	out := bytes.NewBuffer(nil)
+	is.NoError(outputFormat("jsonpath=.name").print(out, items[0], table), "the kubectl shorthand without braces")
+	is.Equal("wordpress\n", out.String())
+
+	is.EqualError(outputFormat(formatTable).print(nil, items, table), "table")
+	is.EqualError(outputFormat("").print(nil, items, table), "table")
+	is.EqualError(outputFormat("xml").validate(), `unknown output format "xml": use table, json, yaml or jsonpath=TEMPLATE`)
+	is.Error(outputFormat("jsonpath=").validate())
+	is.Error(outputFormat("jsonpath={.name").validate())
+	is.EqualError(outputFormat(formatJSON).validateShort(true), "--short and --output cannot be used together")
+	is.NoError(outputFormat(formatTable).validateShort(true))
+}
@@ -53,12 +53,18 @@ func newRootCmd(outputRedirect io.Writer) *cobra.Command {
 		newStatusCmd(outLog),
 		newUninstallCmd(outLog),
 		newUpgradeCmd(outLog),
+		newDiffCmd(outLog),
+		newLogsCmd(outLog),
 		newRunCmd(outLog),
 		newCredentialsCmd(outLog),
+		newParametersCmd(outLog),
 		newClaimsCmd(outLog),
+		newOutputsCmd(outLog),
+		newStackCmd(outLog),
 		newExportCmd(outLog),
 		newImportCmd(outLog),
 		newCreateCmd(outLog),
+		newDoctorCmd(outLog),
 	)
 
 	return cmd
@@ -8,6 +8,8 @@ import (
 
 	"github.com/cnabio/cnab-go/action"
 	"github.com/cnabio/cnab-go/claim"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
 )
 
 func newRunCmd(w io.Writer) *cobra.Command {
@@ -36,6 +38,9 @@ Credentials and parameters may be passed to the bundle during a target action.
 		setParams         []string
 		setFiles          []string
 		relocationMapping string
+		dryRun            bool
+		outputDir         string
+		control           operationControl
 	)
 
 	cmd := &cobra.Command{
@@ -56,12 +61,26 @@ Credentials and parameters may be passed to the bundle during a target action.
 				return err
 			}
 
-			creds, err := loadCredentials(credentialsFiles, c.Bundle)
+			actionDef := c.Bundle.Actions[target]
+			if actionDef.Modifies && !dryRun {
+				lease, err := lockClaim(claimName, target)
+				if err != nil {
+					return err
+				}
+				defer unlockClaim(w, lease)
+				// read the claim again, in case another action changed it before we got the lock
+				if c, err = storage.Read(claimName); err != nil {
+					return err
+				}
+			}
+
+			creds, err := loadCredentials(claimCredentialSets(c, credentialsFiles), c.Bundle)
 			if err != nil {
 				return err
 			}
+			rememberCredentialSets(&c, credentialsFiles)
 
-			driverImpl, err := prepareDriver(driver)
+			driverImpl, err := prepareBundleDriver(driver, c.Bundle, control)
 			if err != nil {
 				return err
 			}
@@ -79,15 +98,38 @@ Credentials and parameters may be passed to the bundle during a target action.
 				return err
 			}
 
+			if dryRun {
+				dryRunDriver := &dryRunDriver{Driver: driverImpl}
+				action := &action.RunCustom{Driver: dryRunDriver, Action: target}
+				if err := action.Run(&c, creds, setOut(cmd.OutOrStdout()), opRelocator); err != nil {
+					return fmt.Errorf("run failed: %s", err)
+				}
+				claimAction := claimKeep
+				if actionDef.Modifies {
+					claimAction = claimStore
+				}
+				return dryRunDriver.printPlan(w, &c, creds, claimAction)
+			}
+
+			oplog, err := startOperationLog(home.Home(homePath()), &c, target, cmd.OutOrStdout(), creds)
+			if err != nil {
+				return err
+			}
+			controlled, release := control.control(driverImpl, oplog)
+			defer release()
 			action := &action.RunCustom{
-				Driver: driverImpl,
+				Driver: controlled,
 				Action: target,
 			}
-
 			fmt.Fprintf(w, "Executing custom action %q for release %q", target, claimName)
-			err = action.Run(&c, creds, setOut(cmd.OutOrStdout()), opRelocator)
-			if actionDef := c.Bundle.Actions[target]; !actionDef.Modifies {
+			err = action.Run(&c, creds, setOut(oplog), opRelocator)
+			recordCancellation(&c, err)
+			finishOperationLog(w, oplog, &c)
+			if !actionDef.Modifies {
 				// Do not store a claim for non-mutating actions.
+				if err == nil && outputDir != "" {
+					return writeOutputs(w, outputDir, c)
+				}
 				return err
 			}
 
@@ -95,15 +137,24 @@ Credentials and parameters may be passed to the bundle during a target action.
 			if err != nil {
 				return fmt.Errorf("run failed: %s", err)
 			}
-			return err2
+			if err2 != nil {
+				return err2
+			}
+			if outputDir != "" {
+				return writeOutputs(w, outputDir, c)
+			}
+			return nil
 		},
 	}
 	flags := cmd.Flags()
 	flags.StringVarP(&driver, "driver", "d", "docker", "Specify a driver name")
 	flags.StringVarP(&relocationMapping, "relocation-mapping", "m", "", "Path of relocation mapping JSON file")
-	flags.StringArrayVarP(&credentialsFiles, "credentials", "c", []string{}, "Specify a set of credentials to use inside the CNAB bundle")
-	flags.StringVarP(&valuesFile, "parameters", "p", "", "Specify file containing parameters. Formats: toml, MORE SOON")
+	flags.StringArrayVarP(&credentialsFiles, "credentials", "c", []string{}, "Specify a set of credentials to use inside the CNAB bundle. Defaults to the credential sets the installation was installed or last upgraded with.")
+	flags.StringVarP(&valuesFile, "parameters", "p", "", "Specify a file containing parameters, or the name of a parameter set. File formats: json, yaml, toml")
 	flags.StringArrayVarP(&setParams, "set", "s", []string{}, "Set individual parameters as NAME=VALUE pairs")
+	flags.StringVar(&outputDir, "output-dir", "", "Write the outputs of the bundle to files in this directory")
+	control.addFlags(flags)
+	flags.BoolVar(&dryRun, "dry-run", false, "Print the operation and the claim that would be stored, without running the driver")
 
 	return cmd
 }
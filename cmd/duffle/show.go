@@ -24,6 +24,7 @@ func newShowCmd(w io.Writer) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.BoolVarP(&bsc.raw, "raw", "r", false, "Display the raw bundle manifest")
+	bsc.output.addShowFlag(flags)
 
 	return cmd
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/osutil"
+	"github.com/cnabio/duffle/pkg/stack"
+)
+
+const stackDesc = `
+Manage several installations together with a stack file.
+
+A stack file declares installations, the bundles they install, and their credentials
+and parameters. The parameters of an installation can use the outputs of other
+installations of the stack:
+
+	name: shop
+	installations:
+	- name: db
+	  bundle: postgres:1.2.0
+	  credentials: [azure]
+	- name: app
+	  bundleFile: app/bundle.json
+	  credentials: [azure]
+	  parameters:
+	    connection_string: ${db.outputs.connection_string}
+	    replicas: 3
+
+Installations are installed and upgraded in an order where every installation comes after
+the installations whose outputs it uses, and uninstalled in the reverse order. Paths of
+bundle files and credential set files are relative to the stack file.
+
+Duffle remembers which installations each stack manages, so that 'duffle stack down' uninstalls
+them even after they were removed from the stack file.
+`
+
+func newStackCmd(w io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stack",
+		Short: "manage several installations together",
+		Long:  stackDesc,
+	}
+
+	cmd.AddCommand(newStackUpCmd(w))
+	cmd.AddCommand(newStackDownCmd(w))
+	cmd.AddCommand(newStackStatusCmd(w))
+
+	return cmd
+}
+
+// stackRecord is the record of the installations a stack manages, in the order they were installed in.
+type stackRecord struct {
+	Name          string   `json:"name"`
+	File          string   `json:"file,omitempty"`
+	Installations []string `json:"installations"`
+}
+
+func stackRecordPath(h home.Home, name string) string {
+	return filepath.Join(h.Stacks(), name+".json")
+}
+
+// loadStackRecord reads the record of the named stack, returning an empty record if the stack is not installed.
+func loadStackRecord(h home.Home, name string) (*stackRecord, error) {
+	rec := &stackRecord{Name: name}
+	data, err := ioutil.ReadFile(stackRecordPath(h, name))
+	if os.IsNotExist(err) {
+		return rec, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("cannot read the record of stack %s: %v", name, err)
+	}
+	return rec, nil
+}
+
+// save writes the record, or removes it if the stack no longer manages any installation.
+func (r *stackRecord) save(h home.Home) error {
+	path := stackRecordPath(h, r.Name)
+	if len(r.Installations) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(h.Stacks(), 0755); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(path, data, 0644)
+}
+
+func (r *stackRecord) has(name string) bool {
+	for _, n := range r.Installations {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// add records name as the last installation installed, unless it is already recorded: upgrades keep the order the
+// installations were first installed in, which stack down uninstalls them in reverse.
+func (r *stackRecord) add(name string) {
+	if !r.has(name) {
+		r.Installations = append(r.Installations, name)
+	}
+}
+
+func (r *stackRecord) remove(name string) {
+	for i, n := range r.Installations {
+		if n == name {
+			r.Installations = append(r.Installations[:i], r.Installations[i+1:]...)
+			return
+		}
+	}
+}
+
+// stackOutputs returns a lookup of the outputs of installations in the claim store. Outputs that the last action did
+// not produce take the default of their definition, if they have one.
+func stackOutputs(storage claim.Store) stack.OutputLookup {
+	return func(ref stack.OutputRef) (interface{}, error) {
+		c, err := storage.Read(ref.Installation)
+		if err == claim.ErrClaimNotFound {
+			return nil, fmt.Errorf("%s is not installed", ref.Installation)
+		} else if err != nil {
+			return nil, err
+		}
+		if v, ok := c.Outputs[ref.Output]; ok {
+			return v, nil
+		}
+		if c.Bundle != nil {
+			if o, ok := c.Bundle.Outputs[ref.Output]; ok {
+				if def, ok := c.Bundle.Definitions[o.Definition]; ok && def.Default != nil {
+					return def.Default, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%s has no output %q", ref.Installation, ref.Output)
+	}
+}
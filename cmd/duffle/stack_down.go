@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/stack"
+)
+
+const stackDownDesc = `
+Uninstall the installations of a stack.
+
+All the installations the stack manages are uninstalled, in the reverse of the order they were
+installed in, including installations that were removed from the stack file. The stack stops
+at the first installation that fails to uninstall; run 'duffle stack down' again to retry.
+
+The stack is named by the stack file, or by NAME when it is given.
+`
+
+type stackDownCmd struct {
+	name    string
+	file    string
+	driver  string
+	control operationControl
+	home    home.Home
+	out     io.Writer
+}
+
+func newStackDownCmd(w io.Writer) *cobra.Command {
+	down := &stackDownCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "down [NAME]",
+		Short: "uninstall the installations of a stack",
+		Long:  stackDownDesc,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				down.name = args[0]
+			}
+			down.home = home.Home(homePath())
+			return down.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&down.file, "file", "f", stack.DefaultFile, "path of the stack file")
+	f.StringVarP(&down.driver, "driver", "d", "docker", "Specify a driver name")
+	down.control.addFlags(f)
+
+	return cmd
+}
+
+func (d *stackDownCmd) run() error {
+	name, err := stackName(d.name, d.file)
+	if err != nil {
+		return err
+	}
+	rec, err := loadStackRecord(d.home, name)
+	if err != nil {
+		return err
+	}
+	if len(rec.Installations) == 0 {
+		return fmt.Errorf("stack %s is not installed", name)
+	}
+
+	storage := claimStorage()
+	for i := len(rec.Installations) - 1; i >= 0; i-- {
+		inst := rec.Installations[i]
+		if _, err := storage.Read(inst); err == claim.ErrClaimNotFound {
+			rec.remove(inst)
+		} else if err != nil {
+			return err
+		} else {
+			fmt.Fprintf(d.out, "==> Uninstalling %s\n", inst)
+			uninstall := &uninstallCmd{
+				name:    inst,
+				out:     d.out,
+				driver:  d.driver,
+				control: d.control,
+			}
+			if err := uninstall.run(); err != nil {
+				return fmt.Errorf("stack %s: %s: %v", name, inst, err)
+			}
+			rec.remove(inst)
+		}
+		if err := rec.save(d.home); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stackName returns name if it is set, or else the name of the stack in file.
+func stackName(name, file string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	s, err := stack.Load(file)
+	if err != nil {
+		return "", err
+	}
+	return s.Name, nil
+}
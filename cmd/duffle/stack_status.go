@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/stack"
+)
+
+const stackStatusDesc = `
+List the installations of a stack with the status of their last action.
+
+Installations are listed in the order they are installed in, followed by the installations
+the stack still manages that were removed from the stack file. When NAME is given instead
+of a stack file, the installations the stack manages are listed.
+`
+
+type stackStatusCmd struct {
+	name   string
+	file   string
+	output outputFormat
+	home   home.Home
+	out    io.Writer
+}
+
+// stackInstallationItem is an installation of a stack as printed by duffle stack status.
+type stackInstallationItem struct {
+	Name       string     `json:"name"`
+	Bundle     string     `json:"bundle"`
+	DependsOn  []string   `json:"dependsOn"`
+	Installed  *time.Time `json:"installed,omitempty"`
+	LastAction string     `json:"lastAction,omitempty"`
+	LastStatus string     `json:"lastStatus,omitempty"`
+	// Managed tells whether the installation is still in the stack file.
+	Managed bool `json:"inStackFile"`
+}
+
+func newStackStatusCmd(w io.Writer) *cobra.Command {
+	status := &stackStatusCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "status [NAME]",
+		Short: "list the installations of a stack",
+		Long:  stackStatusDesc,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				status.name = args[0]
+			}
+			status.home = home.Home(homePath())
+			return status.run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&status.file, "file", "f", stack.DefaultFile, "path of the stack file")
+	status.output.addFlag(cmd.Flags())
+
+	return cmd
+}
+
+func (s *stackStatusCmd) run() error {
+	if err := s.output.validate(); err != nil {
+		return err
+	}
+	var (
+		st    *stack.Stack
+		order []stack.Installation
+		err   error
+	)
+	name := s.name
+	if name == "" {
+		if st, err = stack.Load(s.file); err != nil {
+			return err
+		}
+		if order, err = st.Order(); err != nil {
+			return err
+		}
+		name = st.Name
+	}
+	rec, err := loadStackRecord(s.home, name)
+	if err != nil {
+		return err
+	}
+	if st == nil && len(rec.Installations) == 0 {
+		return fmt.Errorf("stack %s is not installed", name)
+	}
+
+	storage := claimStorage()
+	items := []stackInstallationItem{}
+	add := func(item stackInstallationItem) error {
+		c, err := storage.Read(item.Name)
+		if err == claim.ErrClaimNotFound {
+			items = append(items, item)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if item.Bundle == "" && c.Bundle != nil {
+			item.Bundle = c.Bundle.Name
+		}
+		item.Installed = &c.Created
+		item.LastAction = c.Result.Action
+		item.LastStatus = c.Result.Status
+		items = append(items, item)
+		return nil
+	}
+
+	for _, inst := range order {
+		bun := inst.Bundle
+		if bun == "" {
+			bun = inst.BundleFile
+		}
+		if err := add(stackInstallationItem{Name: inst.Name, Bundle: bun, DependsOn: inst.DependsOn(), Managed: true}); err != nil {
+			return err
+		}
+	}
+	for _, inst := range rec.Installations {
+		if st != nil {
+			if _, ok := st.Installation(inst); ok {
+				continue
+			}
+		}
+		if err := add(stackInstallationItem{Name: inst, DependsOn: []string{}}); err != nil {
+			return err
+		}
+	}
+
+	return s.output.print(s.out, items, func() error {
+		table := uitable.New()
+		table.MaxColWidth = 50
+		table.Wrap = true
+		table.AddRow("NAME", "BUNDLE", "DEPENDS ON", "INSTALLED", "LAST ACTION", "LAST STATUS")
+		for _, item := range items {
+			var installed interface{} = "not installed"
+			if item.Installed != nil {
+				installed = *item.Installed
+			}
+			table.AddRow(item.Name, item.Bundle, strings.Join(item.DependsOn, ", "), installed, item.LastAction, item.LastStatus)
+		}
+		fmt.Fprintln(s.out, table)
+		return nil
+	})
+}
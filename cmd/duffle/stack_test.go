@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+)
+
+const testStack = `name: shop
+installations:
+- name: app
+  bundleFile: app.json
+  parameters:
+    host: ${db.outputs.connection_string}
+    port: 9090
+- name: db
+  bundleFile: db.json
+`
+
+// writeTestStack writes testStack and the bundles of its installations to a directory of h, and returns the path of
+// the stack file.
+func writeTestStack(t *testing.T, h home.Home) string {
+	is := assert.New(t)
+	db := dryRunTestBundle()
+	db.Name = "db"
+	db.Definitions["connection_string"] = &definition.Schema{Type: "string", Default: "postgres://db:5432"}
+	db.Outputs = map[string]bundle.Output{
+		"connection_string": {Definition: "connection_string", Path: "/cnab/app/outputs/connection_string"},
+	}
+	dir := filepath.Join(h.String(), "shop")
+	is.NoError(os.MkdirAll(dir, 0755))
+	for file, bun := range map[string]*bundle.Bundle{"app.json": dryRunTestBundle(), "db.json": db} {
+		data, err := json.Marshal(bun)
+		is.NoError(err)
+		is.NoError(ioutil.WriteFile(filepath.Join(dir, file), data, 0644))
+	}
+	stackFile := filepath.Join(dir, "stack.yaml")
+	is.NoError(ioutil.WriteFile(stackFile, []byte(testStack), 0644))
+	return stackFile
+}
+
+func TestStackUpStatusDown(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+	stackFile := writeTestStack(t, testHome)
+
+	out := bytes.NewBuffer(nil)
+	up := &stackUpCmd{file: stackFile, driver: "debug", home: testHome, out: out}
+	is.NoError(up.run())
+	is.Regexp(`(?s)Installing db.*Installing app`, out.String())
+
+	app, err := claimStorage().Read("app")
+	is.NoError(err)
+	is.Equal("postgres://db:5432", app.Parameters["host"])
+	is.Equal(float64(9090), app.Parameters["port"])
+
+	rec, err := loadStackRecord(testHome, "shop")
+	is.NoError(err)
+	is.Equal([]string{"db", "app"}, rec.Installations)
+
+	// a second run upgrades the installations in the stack file, and leaves the removed ones alone
+	is.NoError(ioutil.WriteFile(stackFile, []byte("name: shop\ninstallations:\n- name: db\n  bundleFile: db.json\n"), 0644))
+	out.Reset()
+	is.NoError(up.run())
+	is.Contains(out.String(), "Upgrading db")
+	is.Contains(out.String(), "app is no longer in the stack file")
+	c, err := claimStorage().Read("db")
+	is.NoError(err)
+	is.Equal(claim.ActionUpgrade, c.Result.Action)
+
+	out.Reset()
+	is.NoError((&stackStatusCmd{file: stackFile, home: testHome, out: out}).run())
+	is.Regexp(`db\s+db.json\s+.*upgrade\s+success`, out.String())
+	is.Regexp(`app\s+dryrun\s+.*install\s+success`, out.String())
+
+	out.Reset()
+	is.NoError((&stackDownCmd{name: "shop", driver: "debug", home: testHome, out: out}).run())
+	is.Regexp(`(?s)Uninstalling app.*Uninstalling db`, out.String(), "installations are uninstalled in the reverse order of their install")
+	for _, name := range []string{"db", "app"} {
+		_, err := claimStorage().Read(name)
+		is.Equal(claim.ErrClaimNotFound, err)
+	}
+	is.False(fileExists(stackRecordPath(testHome, "shop")))
+	is.EqualError((&stackStatusCmd{name: "shop", home: testHome, out: out}).run(), "stack shop is not installed")
+}
+
+func TestStackUpRefusesUnmanagedInstallations(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	data, err := json.Marshal(dryRunTestBundle())
+	is.NoError(err)
+	is.NoError(ioutil.WriteFile(filepath.Join(testHome.String(), "db.json"), data, 0644))
+	install := &installCmd{bundle: filepath.Join(testHome.String(), "db.json"), bundleIsFile: true, name: "db", home: testHome, out: ioutil.Discard, driver: "debug"}
+	is.NoError(install.run())
+
+	stackFile := filepath.Join(testHome.String(), "stack.yaml")
+	is.NoError(ioutil.WriteFile(stackFile, []byte("name: shop\ninstallations:\n- name: db\n  bundleFile: db.json\n"), 0644))
+	err = (&stackUpCmd{file: stackFile, driver: "debug", home: testHome, out: ioutil.Discard}).run()
+	is.EqualError(err, "installation db already exists and is not managed by stack shop")
+}
+
+func TestStackUpManagesFailedInstallations(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+	defer brokenDriver(t, testHome.String())()
+
+	data, err := json.Marshal(dryRunTestBundle())
+	is.NoError(err)
+	is.NoError(ioutil.WriteFile(filepath.Join(testHome.String(), "db.json"), data, 0644))
+	stackFile := filepath.Join(testHome.String(), "stack.yaml")
+	is.NoError(ioutil.WriteFile(stackFile, []byte("name: shop\ninstallations:\n- name: db\n  bundleFile: db.json\n"), 0644))
+
+	is.Error((&stackUpCmd{file: stackFile, driver: "broken", home: testHome, out: ioutil.Discard}).run())
+	c, err := claimStorage().Read("db")
+	is.NoError(err, "a failed install stores a claim")
+	is.NotEqual(claim.StatusSuccess, c.Result.Status)
+	rec, err := loadStackRecord(testHome, "shop")
+	is.NoError(err)
+	is.Equal([]string{"db"}, rec.Installations, "the stack manages the failed installation")
+
+	// the next run upgrades the failed installation rather than refusing it, and down uninstalls it
+	out := bytes.NewBuffer(nil)
+	is.NoError((&stackUpCmd{file: stackFile, driver: "debug", home: testHome, out: out}).run())
+	is.Contains(out.String(), "Upgrading db")
+	is.NoError((&stackDownCmd{name: "shop", driver: "debug", home: testHome, out: ioutil.Discard}).run())
+	_, err = claimStorage().Read("db")
+	is.Equal(claim.ErrClaimNotFound, err)
+}
+
+func TestStackDownAfterFailedUpgrade(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+	defer brokenDriver(t, testHome.String())()
+	stackFile := writeTestStack(t, testHome)
+
+	is.NoError((&stackUpCmd{file: stackFile, driver: "debug", home: testHome, out: ioutil.Discard}).run())
+	out := bytes.NewBuffer(nil)
+	is.Error((&stackUpCmd{file: stackFile, driver: "broken", home: testHome, out: out}).run())
+	is.Contains(out.String(), "Upgrading db")
+	rec, err := loadStackRecord(testHome, "shop")
+	is.NoError(err)
+	is.Equal([]string{"db", "app"}, rec.Installations, "a failed upgrade keeps the install order")
+
+	out.Reset()
+	is.NoError((&stackDownCmd{name: "shop", driver: "debug", home: testHome, out: out}).run())
+	is.Regexp(`(?s)Uninstalling app.*Uninstalling db`, out.String(), "app is uninstalled before the db it consumes the outputs of")
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/spf13/cobra"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/ohai"
+	"github.com/cnabio/duffle/pkg/stack"
+)
+
+const stackUpDesc = `
+Install or upgrade the installations of a stack.
+
+Installations that do not exist yet are installed, and the others are upgraded to the bundle
+and parameters of the stack file. Parameters that are not set in the stack file take their
+defaults. Installations are run one at a time, each after the installations whose outputs
+it uses, and the stack stops at the first one that fails.
+
+Installations that were removed from the stack file are left alone, and are uninstalled
+by 'duffle stack down'.
+`
+
+type stackUpCmd struct {
+	file    string
+	driver  string
+	control operationControl
+	home    home.Home
+	out     io.Writer
+}
+
+func newStackUpCmd(w io.Writer) *cobra.Command {
+	up := &stackUpCmd{out: w}
+
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "install or upgrade the installations of a stack",
+		Long:  stackUpDesc,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			up.home = home.Home(homePath())
+			return up.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&up.file, "file", "f", stack.DefaultFile, "path of the stack file")
+	f.StringVarP(&up.driver, "driver", "d", "docker", "Specify a driver name")
+	up.control.addFlags(f)
+
+	return cmd
+}
+
+func (u *stackUpCmd) run() error {
+	s, err := stack.Load(u.file)
+	if err != nil {
+		return err
+	}
+	order, err := s.Order()
+	if err != nil {
+		return err
+	}
+	rec, err := loadStackRecord(u.home, s.Name)
+	if err != nil {
+		return err
+	}
+	if rec.File, err = filepath.Abs(u.file); err != nil {
+		return err
+	}
+
+	storage := claimStorage()
+	lookup := stackOutputs(storage)
+	for _, inst := range order {
+		_, err := storage.Read(inst.Name)
+		installed := err == nil
+		if err != nil && err != claim.ErrClaimNotFound {
+			return err
+		}
+		if installed && !rec.has(inst.Name) {
+			return fmt.Errorf("installation %s already exists and is not managed by stack %s", inst.Name, s.Name)
+		}
+
+		params, err := inst.ResolveParameters(lookup)
+		if err != nil {
+			return err
+		}
+		if installed {
+			fmt.Fprintf(u.out, "==> Upgrading %s\n", inst.Name)
+			err = u.upgrade(s, inst, params)
+		} else {
+			fmt.Fprintf(u.out, "==> Installing %s\n", inst.Name)
+			err = u.install(s, inst, params)
+		}
+		// a failed install still stores a claim, which the stack must manage to upgrade or uninstall it later
+		if _, stored := storage.Read(inst.Name); stored == nil {
+			rec.add(inst.Name)
+			if serr := rec.save(u.home); serr != nil && err == nil {
+				err = serr
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("stack %s: %s: %v", s.Name, inst.Name, err)
+		}
+	}
+
+	for _, name := range rec.Installations {
+		if _, ok := s.Installation(name); !ok {
+			ohai.Fwarningf(u.out, "%s is no longer in the stack file; it is uninstalled by 'duffle stack down'\n", name)
+		}
+	}
+	return nil
+}
+
+func (u *stackUpCmd) install(s *stack.Stack, inst stack.Installation, params map[string]interface{}) error {
+	install := &installCmd{
+		bundle:           inst.Bundle,
+		name:             inst.Name,
+		home:             u.home,
+		out:              u.out,
+		driver:           u.driver,
+		credentialsFiles: stackCredentials(s, inst),
+		control:          u.control,
+		params:           params,
+	}
+	if inst.BundleFile != "" {
+		install.bundle = s.Path(inst.BundleFile)
+		install.bundleIsFile = true
+	}
+	return install.run()
+}
+
+func (u *stackUpCmd) upgrade(s *stack.Stack, inst stack.Installation, params map[string]interface{}) error {
+	upgrade := &upgradeCmd{
+		name:             inst.Name,
+		out:              u.out,
+		driver:           u.driver,
+		bundle:           inst.Bundle,
+		bundleFile:       s.Path(inst.BundleFile),
+		credentialsFiles: stackCredentials(s, inst),
+		control:          u.control,
+		params:           params,
+	}
+	if err := upgrade.setup(); err != nil {
+		return err
+	}
+	return upgrade.run()
+}
+
+// stackCredentials returns the credential sets of an installation, with the paths of credential set files resolved
+// against the stack file.
+func stackCredentials(s *stack.Stack, inst stack.Installation) []string {
+	creds := make([]string, len(inst.Credentials))
+	for i, c := range inst.Credentials {
+		if path := s.Path(c); fileExists(path) {
+			c = path
+		}
+		creds[i] = c
+	}
+	return creds
+}
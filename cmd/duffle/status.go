@@ -4,86 +4,379 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/Masterminds/semver"
 	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
 
 	"github.com/cnabio/cnab-go/action"
 	"github.com/cnabio/cnab-go/claim"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/ohai"
+	"github.com/cnabio/duffle/pkg/repo"
 )
 
-func newStatusCmd(w io.Writer) *cobra.Command {
-	const short = "get the status of an installation"
-	const long = `Gets the status of an existing installation.
+const statusDesc = `Gets the status of an existing installation.
 
 Given an installation name, execute the status task for this. A status
 action will restart the CNAB image and ask it to query for status. For that
 reason, it may need the same credentials used to install.
+
+The values of credentials and write-only parameters are masked in the output.
+
+With '--local', the status action is not run. Only the state Duffle keeps about the
+installation is shown: its revisions, last action, outputs and bundle version, and
+whether a newer version of the bundle is in the local store. This needs neither
+credentials nor a driver, so it works offline.
+
+With '--watch', the status is shown again every '--interval' until Duffle is interrupted.
+
+With '-o json', '-o yaml' or '-o jsonpath=...', the installation is printed in that format, and
+the output of the status action is written to stderr.
+
+Ex. $ duffle status my-app
+    $ duffle status my-app --local
+    $ duffle status my-app --watch --interval 30s
 `
-	var (
-		statusDriver      string
-		credentialsFiles  []string
-		relocationMapping string
-	)
+
+type statusCmd struct {
+	name              string
+	driver            string
+	credentialsFiles  []string
+	relocationMapping string
+	dryRun            bool
+	local             bool
+	watch             bool
+	interval          time.Duration
+	control           operationControl
+	output            outputFormat
+	home              home.Home
+	out               io.Writer
+}
+
+func newStatusCmd(w io.Writer) *cobra.Command {
+	const short = "get the status of an installation"
+	status := &statusCmd{out: w}
 
 	cmd := &cobra.Command{
 		Use:   "status NAME",
 		Short: short,
-		Long:  long,
+		Long:  statusDesc,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return errors.New("required arg is NAME (installation name")
 			}
-			claimName := args[0]
-			c, err := loadClaim(claimName)
-			if err != nil {
-				if err == claim.ErrClaimNotFound {
-					return fmt.Errorf("Bundle installation '%s' not found", claimName)
-				}
+			status.name = args[0]
+			status.home = home.Home(homePath())
+			return status.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&status.driver, "driver", "d", "docker", "Specify a driver name")
+	f.StringArrayVarP(&status.credentialsFiles, "credentials", "c", []string{}, "Specify credentials to use inside the CNAB bundle. This can be a credentialset name or a path to a file. Defaults to the credential sets the installation was installed or last upgraded with.")
+	f.StringVarP(&status.relocationMapping, "relocation-mapping", "m", "", "Path of relocation mapping JSON file")
+	status.control.addFlags(f)
+	status.output.addFlag(f)
+	f.BoolVar(&status.dryRun, "dry-run", false, "Print the operation that would be run, without running the driver")
+	f.BoolVar(&status.local, "local", false, "Only show the state kept by Duffle, without running the status action")
+	f.BoolVarP(&status.watch, "watch", "w", false, "Show the status again every interval until interrupted")
+	f.DurationVar(&status.interval, "interval", 10*time.Second, "How often the status is shown with --watch")
+
+	return cmd
+}
+
+func (s *statusCmd) run() error {
+	if err := s.output.validate(); err != nil {
+		return err
+	}
+	if s.local && s.dryRun {
+		return errors.New("--local and --dry-run cannot be used together")
+	}
+	if s.watch && s.interval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+	if !s.watch {
+		return s.show()
+	}
+
+	// an interrupt while waiting ends the watch; one while the action runs also cancels it through the operation control
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	for {
+		// a failed status does not end the watch, unless the user cancelled it
+		if err := s.show(); err != nil {
+			var cancelled *operationCancelledError
+			if errors.As(err, &cancelled) && cancelled.timeout == 0 {
 				return err
 			}
+			warnings := s.out
+			if !s.output.table() {
+				warnings = os.Stderr
+			}
+			ohai.Fwarningf(warnings, "%v\n", err)
+		}
+		select {
+		case <-sigs:
+			return nil
+		case <-time.After(s.interval):
+		}
+		if s.output.table() {
+			fmt.Fprintln(s.out)
+		}
+	}
+}
+
+// show prints the installation and runs its status action, unless the status is local.
+func (s *statusCmd) show() error {
+	c, err := loadClaim(s.name)
+	if err != nil {
+		if err == claim.ErrClaimNotFound {
+			return fmt.Errorf("Bundle installation '%s' not found", s.name)
+		}
+		return err
+	}
 
-			//display information about the bundle installation
+	if s.local {
+		item, err := newLocalStatusItem(s.home, c)
+		if err != nil {
+			return err
+		}
+		return s.output.print(s.out, item, func() error {
+			printStatusTable(s.out, item.statusItem, func(table *uitable.Table) {
+				table.AddRow("Bundle Version:", item.Version)
+				newer := "none"
+				if item.NewerVersion != "" {
+					newer = item.NewerVersion
+				}
+				table.AddRow("Newer Version in Store:", newer)
+				table.AddRow("Revisions:", strings.Join(item.Revisions, ", "))
+			})
+			if len(item.Outputs) == 0 {
+				return nil
+			}
 			table := uitable.New()
 			table.MaxColWidth = 80
 			table.Wrap = true
-
-			table.AddRow("Installation Name:", c.Name)
-			table.AddRow("Installed at:", c.Created)
-			table.AddRow("Last Modified at:", c.Modified)
-			table.AddRow("Current Revision:", c.Revision)
-			table.AddRow("Bundle:", c.Bundle.Name)
-			table.AddRow("Last Action Performed:", c.Result.Action)
-			table.AddRow("Last Action Status:", c.Result.Status)
-			table.AddRow("Last Action Message:", c.Result.Message)
-			fmt.Println(table)
-
-			creds, err := loadCredentials(credentialsFiles, c.Bundle)
-			if err != nil {
-				return err
+			table.AddRow("OUTPUT", "VALUE")
+			for _, name := range sortedKeys(item.Outputs) {
+				table.AddRow(name, item.Outputs[name])
 			}
+			fmt.Fprintln(s.out, table)
+			return nil
+		})
+	}
 
-			driverImpl, err := prepareDriver(statusDriver)
-			if err != nil {
-				return err
-			}
+	//display information about the bundle installation
+	item := newStatusItem(c)
+	err = s.output.print(s.out, item, func() error {
+		printStatusTable(s.out, item, nil)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-			opRelocator, err := makeOpRelocator(relocationMapping)
-			if err != nil {
-				return err
-			}
+	// keep the output of the action out of documents meant for other programs
+	actionOut := s.out
+	if !s.output.table() {
+		actionOut = os.Stderr
+	}
 
-			// TODO: Do we pass new values in here? Or just from Claim?
-			action := &action.Status{Driver: driverImpl}
-			fmt.Println("Executing status action in bundle...")
-			return action.Run(&c, creds, setOut(cmd.OutOrStdout()), opRelocator)
-		},
+	creds, err := loadCredentials(claimCredentialSets(c, s.credentialsFiles), c.Bundle)
+	if err != nil {
+		return err
 	}
-	cmd.Flags().StringVarP(&statusDriver, "driver", "d", "docker", "Specify a driver name")
-	cmd.Flags().StringArrayVarP(&credentialsFiles, "credentials", "c", []string{}, "Specify credentials to use inside the CNAB bundle. This can be a credentialset name or a path to a file.")
-	cmd.Flags().StringVarP(&relocationMapping, "relocation-mapping", "m", "", "Path of relocation mapping JSON file")
 
-	return cmd
+	driverImpl, err := prepareBundleDriver(s.driver, c.Bundle, s.control)
+	if err != nil {
+		return err
+	}
+
+	opRelocator, err := makeOpRelocator(s.relocationMapping)
+	if err != nil {
+		return err
+	}
+
+	if s.dryRun {
+		dryRunDriver := &dryRunDriver{Driver: driverImpl}
+		action := &action.Status{Driver: dryRunDriver}
+		if err := action.Run(&c, creds, setOut(actionOut), opRelocator); err != nil {
+			return err
+		}
+		return dryRunDriver.printPlan(actionOut, &c, creds, claimKeep)
+	}
+
+	// TODO: Do we pass new values in here? Or just from Claim?
+	oplog, err := startOperationLog(s.home, &c, claim.ActionStatus, actionOut, creds)
+	if err != nil {
+		return err
+	}
+	controlled, release := s.control.control(driverImpl, oplog)
+	defer release()
+	action := &action.Status{Driver: controlled}
+	fmt.Fprintln(actionOut, "Executing status action in bundle...")
+	err = action.Run(&c, creds, setOut(oplog), opRelocator)
+	finishOperationLog(actionOut, oplog, &c)
+	return err
+}
+
+// printStatusTable prints the installation as a table, with the rows added by more, if it is not nil.
+func printStatusTable(w io.Writer, item statusItem, more func(*uitable.Table)) {
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+
+	table.AddRow("Installation Name:", item.Name)
+	table.AddRow("Installed at:", item.Installed)
+	table.AddRow("Last Modified at:", item.Modified)
+	table.AddRow("Current Revision:", item.Revision)
+	table.AddRow("Bundle:", item.Bundle)
+	table.AddRow("Last Action Performed:", item.LastAction)
+	table.AddRow("Last Action Status:", item.LastStatus)
+	table.AddRow("Last Action Message:", item.LastMessage)
+	if more != nil {
+		more(table)
+	}
+	fmt.Fprintln(w, table)
+}
+
+// statusItem is an installation as printed by duffle status.
+type statusItem struct {
+	Name        string    `json:"name"`
+	Bundle      string    `json:"bundle"`
+	Version     string    `json:"version"`
+	Installed   time.Time `json:"installed"`
+	Modified    time.Time `json:"modified"`
+	Revision    string    `json:"revision"`
+	LastAction  string    `json:"lastAction"`
+	LastStatus  string    `json:"lastStatus"`
+	LastMessage string    `json:"lastMessage"`
+}
+
+func newStatusItem(c claim.Claim) statusItem {
+	item := statusItem{
+		Name:        c.Name,
+		Installed:   c.Created,
+		Modified:    c.Modified,
+		Revision:    c.Revision,
+		LastAction:  c.Result.Action,
+		LastStatus:  c.Result.Status,
+		LastMessage: maskClaim(c).Result.Message,
+	}
+	if c.Bundle != nil {
+		item.Bundle = c.Bundle.Name
+		item.Version = c.Bundle.Version
+	}
+	return item
+}
+
+// localStatusItem is an installation as printed by duffle status --local.
+type localStatusItem struct {
+	statusItem
+	// Revisions are the revisions of the installation that Duffle has a log of, oldest first.
+	Revisions []string               `json:"revisions"`
+	Outputs   map[string]interface{} `json:"outputs"`
+	// NewerVersion is the latest version of the bundle in the local store, if it is newer than the installed one.
+	NewerVersion string `json:"newerVersion,omitempty"`
+}
+
+func newLocalStatusItem(h home.Home, c claim.Claim) (localStatusItem, error) {
+	item := localStatusItem{
+		statusItem: newStatusItem(c),
+		Outputs:    maskClaim(c).Outputs,
+	}
+	if item.Outputs == nil {
+		item.Outputs = map[string]interface{}{}
+	}
+
+	revisions, err := claimRevisions(h, c)
+	if err != nil {
+		return item, err
+	}
+	item.Revisions = revisions
+
+	if c.Bundle != nil {
+		newer, err := newerBundleVersion(h, c.Bundle.Name, c.Bundle.Version)
+		if err != nil {
+			return item, err
+		}
+		item.NewerVersion = newer
+	}
+	return item, nil
+}
+
+// claimRevisions returns the revisions of the installation c, oldest first. Revisions are known from their operation
+// logs, so older revisions are missing for installations made before Duffle kept logs.
+func claimRevisions(h home.Home, c claim.Claim) ([]string, error) {
+	files, err := ioutil.ReadDir(claimLogDir(h, c.Name))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	revisions := []string{}
+	current := false
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, logExt) {
+			continue
+		}
+		revision := strings.TrimSuffix(name, logExt)
+		revisions = append(revisions, revision)
+		current = current || revision == c.Revision
+	}
+	if !current && c.Revision != "" {
+		revisions = append(revisions, c.Revision)
+	}
+	// revisions are ULIDs, which sort by time
+	sort.Strings(revisions)
+	return revisions, nil
+}
+
+// newerBundleVersion returns the latest version of the named bundle in the local store, if it is newer than version.
+// Versions that are not semantic versions are not compared.
+func newerBundleVersion(h home.Home, name, version string) (string, error) {
+	current, err := semver.NewVersion(version)
+	if err != nil {
+		return "", nil
+	}
+	index, err := repo.LoadIndex(h.Repositories())
+	if err != nil {
+		return "", fmt.Errorf("cannot open %s: %v", h.Repositories(), err)
+	}
+	versions, ok := index.GetVersions(name)
+	if !ok {
+		return "", nil
+	}
+
+	var newest *semver.Version
+	for _, v := range versions {
+		if v.Version.GreaterThan(current) && (newest == nil || v.Version.GreaterThan(newest)) {
+			newest = v.Version
+		}
+	}
+	if newest == nil {
+		return "", nil
+	}
+	return newest.Original(), nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func loadClaim(name string) (claim.Claim, error) {
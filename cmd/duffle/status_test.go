@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusLocal(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	data, err := json.Marshal(dryRunTestBundle())
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+
+	out := bytes.NewBuffer(nil)
+	install := &installCmd{bundle: bundleFile, bundleIsFile: true, name: "local", home: testHome, out: out, driver: "debug"}
+	is.NoError(install.run())
+	c, err := claimStorage().Read("local")
+	is.NoError(err)
+
+	// the driver is never prepared, so an unknown one does not matter
+	status := &statusCmd{name: "local", driver: "nope", local: true, home: testHome, out: out}
+	out.Reset()
+	is.NoError(status.run())
+	is.Regexp(`Bundle Version:\s+0.1.0`, out.String())
+	is.Regexp(`Newer Version in Store:\s+none`, out.String())
+	is.Regexp(`Revisions:\s+`+c.Revision, out.String())
+
+	is.NoError(recordBundleReference(testHome, "dryrun", "0.1.0", "sha256:0000000000000000000000000000000000000000000000000000000000000001"))
+	is.NoError(recordBundleReference(testHome, "dryrun", "0.3.0", "sha256:0000000000000000000000000000000000000000000000000000000000000003"))
+	is.NoError(recordBundleReference(testHome, "dryrun", "0.2.0", "sha256:0000000000000000000000000000000000000000000000000000000000000002"))
+
+	status.output = formatJSON
+	out.Reset()
+	is.NoError(status.run())
+	var item map[string]interface{}
+	is.NoError(json.Unmarshal(out.Bytes(), &item))
+	is.Equal("local", item["name"])
+	is.Equal("0.1.0", item["version"])
+	is.Equal("0.3.0", item["newerVersion"])
+	is.Equal([]interface{}{c.Revision}, item["revisions"])
+	is.Equal(map[string]interface{}{}, item["outputs"])
+
+	is.EqualError((&statusCmd{name: "nope", local: true, home: testHome, out: out}).run(), "Bundle installation 'nope' not found")
+	is.EqualError((&statusCmd{name: "local", local: true, dryRun: true, home: testHome, out: out}).run(), "--local and --dry-run cannot be used together")
+	is.EqualError((&statusCmd{name: "local", watch: true, home: testHome, out: out}).run(), "--interval must be positive")
+}
+
+func TestStatusWatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupts cannot be sent to the process on windows")
+	}
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	data, err := json.Marshal(dryRunTestBundle())
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+	is.NoError((&installCmd{bundle: bundleFile, bundleIsFile: true, name: "watched", home: testHome, out: ioutil.Discard, driver: "debug"}).run())
+
+	p, err := os.FindProcess(os.Getpid())
+	is.NoError(err)
+	go func() {
+		time.Sleep(120 * time.Millisecond)
+		p.Signal(os.Interrupt)
+	}()
+
+	out := bytes.NewBuffer(nil)
+	status := &statusCmd{name: "watched", driver: "debug", local: true, watch: true, interval: 50 * time.Millisecond, home: testHome, out: out}
+	is.NoError(status.run(), "an interrupt between statuses ends the watch")
+	is.True(bytes.Count(out.Bytes(), []byte("Installation Name:")) >= 2, out.String())
+}
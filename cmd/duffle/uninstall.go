@@ -8,6 +8,10 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/cnabio/cnab-go/action"
+	"github.com/cnabio/cnab-go/claim"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
+	"github.com/cnabio/duffle/pkg/ohai"
 )
 
 const uninstallUsage = `Uninstalls an installation of a CNAB bundle.
@@ -16,6 +20,14 @@ When using '--parameters' or '--set', the uninstall command will replace the old
 parameters with the new ones supplied (even if the new set is an empty set). If neither
 '--parameters' nor '--set' is passed, then the parameters used for 'duffle install' will
 be re-used.
+
+The claim of the installation is deleted once the uninstall action succeeds. Use
+'--keep-claim' to keep it instead, recording the uninstall. If the uninstall action fails,
+for example because the invocation image no longer exists, the claim is kept, unless
+'--force' is passed. To delete a claim without running anything, use 'duffle claims delete'.
+
+The installations of the dependencies of the bundle are uninstalled after the installation,
+in the reverse of the order they were installed in.
 `
 
 type uninstallCmd struct {
@@ -28,6 +40,10 @@ type uninstallCmd struct {
 	setParams         []string
 	credentialsFiles  []string
 	relocationMapping string
+	dryRun            bool
+	control           operationControl
+	force             bool
+	keepClaim         bool
 }
 
 func newUninstallCmd(w io.Writer) *cobra.Command {
@@ -50,11 +66,15 @@ func newUninstallCmd(w io.Writer) *cobra.Command {
 	flags := cmd.Flags()
 	flags.StringVarP(&uninstall.relocationMapping, "relocation-mapping", "m", "", "Path of relocation mapping JSON file")
 	flags.StringVarP(&uninstall.driver, "driver", "d", "docker", "Specify a driver name")
-	flags.StringArrayVarP(&uninstall.credentialsFiles, "credentials", "c", []string{}, "Specify credentials to use inside the CNAB bundle. This can be a credentialset name or a path to a file.")
-	flags.StringVarP(&uninstall.valuesFile, "parameters", "p", "", "Specify file containing parameters. Formats: toml, MORE SOON")
+	flags.StringArrayVarP(&uninstall.credentialsFiles, "credentials", "c", []string{}, "Specify credentials to use inside the CNAB bundle. This can be a credentialset name or a path to a file. Defaults to the credential sets the installation was installed or last upgraded with.")
+	flags.StringVarP(&uninstall.valuesFile, "parameters", "p", "", "Specify a file containing parameters, or the name of a parameter set. File formats: json, yaml, toml")
 	flags.StringVarP(&uninstall.bundle, "bundle", "b", "", "bundle to uninstall")
 	flags.StringVar(&uninstall.bundleFile, "bundle-file", "", "path to a bundle file to uninstall")
 	flags.StringArrayVarP(&uninstall.setParams, "set", "s", []string{}, "set individual parameters as NAME=VALUE pairs")
+	uninstall.control.addFlags(flags)
+	flags.BoolVar(&uninstall.dryRun, "dry-run", false, "print the operation that would be run, without running the driver or deleting the claim")
+	flags.BoolVar(&uninstall.force, "force", false, "delete the claim even if the uninstall action fails")
+	flags.BoolVar(&uninstall.keepClaim, "keep-claim", false, "keep the claim after the uninstall action succeeds")
 
 	return cmd
 }
@@ -70,7 +90,19 @@ func (un *uninstallCmd) setup() error {
 }
 
 func (un *uninstallCmd) run() error {
-	claim, err := claimStorage().Read(un.name)
+	if un.force && un.keepClaim {
+		return errors.New("--force and --keep-claim cannot be used together")
+	}
+
+	if !un.dryRun {
+		lease, err := lockClaim(un.name, claim.ActionUninstall)
+		if err != nil {
+			return err
+		}
+		defer unlockClaim(un.out, lease)
+	}
+
+	c, err := claimStorage().Read(un.name)
 	if err != nil {
 		return fmt.Errorf("%v not found: %v", un.name, err)
 	}
@@ -80,28 +112,28 @@ func (un *uninstallCmd) run() error {
 		if err != nil {
 			return err
 		}
-		claim.Bundle = b
+		c.Bundle = b
 	}
 
 	// If no params are specified, allow re-use. But if params are set -- even if empty --
 	// replace the existing params.
 	if len(un.setParams) > 0 || un.valuesFile != "" {
-		if claim.Bundle == nil {
+		if c.Bundle == nil {
 			return errors.New("parameters can only be set if a bundle is provided")
 		}
-		params, err := calculateParamValues(claim.Bundle, un.valuesFile, un.setParams, []string{})
+		params, err := calculateParamValues(c.Bundle, un.valuesFile, un.setParams, []string{})
 		if err != nil {
 			return err
 		}
-		claim.Parameters = params
+		c.Parameters = params
 	}
 
-	driverImpl, err := prepareDriver(un.driver)
+	driverImpl, err := prepareBundleDriver(un.driver, c.Bundle, un.control)
 	if err != nil {
 		return fmt.Errorf("could not prepare driver: %s", err)
 	}
 
-	creds, err := loadCredentials(un.credentialsFiles, claim.Bundle)
+	creds, err := loadCredentials(claimCredentialSets(c, un.credentialsFiles), c.Bundle)
 	if err != nil {
 		return fmt.Errorf("could not load credentials: %s", err)
 	}
@@ -111,13 +143,52 @@ func (un *uninstallCmd) run() error {
 		return err
 	}
 
-	uninst := &action.Uninstall{
-		Driver: driverImpl,
+	if un.dryRun {
+		dryRun := &dryRunDriver{Driver: driverImpl}
+		uninst := &action.Uninstall{Driver: dryRun}
+		if err := uninst.Run(&c, creds, setOut(un.out), opRelocator); err != nil {
+			return fmt.Errorf("could not uninstall %q: %s", un.name, err)
+		}
+		claimAction := claimDelete
+		if un.keepClaim {
+			claimAction = claimStore
+		}
+		if err := dryRun.printPlan(un.out, &c, creds, claimAction); err != nil {
+			return err
+		}
+		return un.uninstallDependencies(readClaimData(c).Dependencies)
 	}
 
+	oplog, err := startOperationLog(home.Home(homePath()), &c, claim.ActionUninstall, un.out, creds)
+	if err != nil {
+		return err
+	}
+	controlled, release := un.control.control(driverImpl, oplog)
+	defer release()
+	uninst := &action.Uninstall{
+		Driver: controlled,
+	}
 	fmt.Fprintln(un.out, "Executing uninstall action...")
-	if err := uninst.Run(&claim, creds, setOut(un.out), opRelocator); err != nil {
-		return fmt.Errorf("could not uninstall %q: %s", un.name, err)
+	err = uninst.Run(&c, creds, setOut(oplog), opRelocator)
+	recordCancellation(&c, err)
+	finishOperationLog(un.out, oplog, &c)
+	if err != nil {
+		if !un.force {
+			// keep the claim, recording the failure
+			if err2 := claimStorage().Store(c); err2 != nil {
+				ohai.Fwarningf(un.out, "could not store the claim of %q: %v\n", un.name, err2)
+			}
+			return fmt.Errorf("could not uninstall %q: %s", un.name, err)
+		}
+		ohai.Fwarningf(un.out, "the uninstall action failed, deleting the claim of %q anyway: %s\n", un.name, err)
+	}
+	if un.keepClaim {
+		err = claimStorage().Store(c)
+	} else {
+		err = claimStorage().Delete(un.name)
+	}
+	if err != nil {
+		return err
 	}
-	return claimStorage().Delete(un.name)
+	return un.uninstallDependencies(readClaimData(c).Dependencies)
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/stretchr/testify/assert"
+)
+
+// brokenDriver puts a driver named "broken", which always fails, on the PATH, and returns a function that restores
+// the PATH.
+func brokenDriver(t *testing.T, dir string) func() {
+	if runtime.GOOS == "windows" {
+		t.Skip("the broken driver is a shell script")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "cnab-broken"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return func() { os.Setenv("PATH", path) }
+}
+
+func TestUninstallForceAndKeepClaim(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+	defer brokenDriver(t, testHome.String())()
+
+	data, err := json.Marshal(dryRunTestBundle())
+	is.NoError(err)
+	bundleFile := filepath.Join(testHome.String(), "bundle.json")
+	is.NoError(ioutil.WriteFile(bundleFile, data, 0644))
+	for _, name := range []string{"broken", "kept"} {
+		install := &installCmd{bundle: bundleFile, bundleIsFile: true, name: name, home: testHome, out: ioutil.Discard, driver: "debug"}
+		is.NoError(install.run())
+	}
+
+	err = (&uninstallCmd{name: "broken", out: ioutil.Discard, driver: "broken"}).run()
+	is.Error(err)
+	_, err = claimStorage().Read("broken")
+	is.NoError(err, "the claim is kept when the uninstall action fails")
+
+	err = (&uninstallCmd{name: "broken", out: ioutil.Discard, driver: "broken", force: true, keepClaim: true}).run()
+	is.EqualError(err, "--force and --keep-claim cannot be used together")
+
+	out := bytes.NewBuffer(nil)
+	is.NoError((&uninstallCmd{name: "broken", out: out, driver: "broken", force: true}).run())
+	is.Contains(out.String(), `the uninstall action failed, deleting the claim of "broken" anyway`)
+	_, err = claimStorage().Read("broken")
+	is.Equal(claim.ErrClaimNotFound, err)
+
+	is.NoError((&uninstallCmd{name: "kept", out: ioutil.Discard, driver: "debug", keepClaim: true}).run())
+	c, err := claimStorage().Read("kept")
+	is.NoError(err)
+	is.Equal(claim.ActionUninstall, c.Result.Action)
+	is.Equal(claim.StatusSuccess, c.Result.Status)
+}
+
+func TestClaimsDelete(t *testing.T) {
+	is := assert.New(t)
+	testHome := CreateTestHome(t)
+	defer os.RemoveAll(testHome.String())
+
+	c, err := claim.New("forgotten")
+	is.NoError(err)
+	c.Bundle = dryRunTestBundle()
+	is.NoError(claimStorage().Store(*c))
+
+	out := bytes.NewBuffer(nil)
+	is.NoError((&claimsDeleteCmd{name: "forgotten", out: out}).run())
+	is.Equal("Deleted claim forgotten\n", out.String())
+	_, err = claimStorage().Read("forgotten")
+	is.Equal(claim.ErrClaimNotFound, err)
+
+	err = (&claimsDeleteCmd{name: "forgotten", out: out}).run()
+	is.EqualError(err, "Bundle installation 'forgotten' not found")
+}
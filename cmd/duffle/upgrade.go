@@ -9,6 +9,10 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/cnabio/cnab-go/action"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+
+	"github.com/cnabio/duffle/pkg/duffle/home"
 )
 
 const upgradeUsage = `perform the upgrade action in the CNAB bundle`
@@ -24,6 +28,9 @@ to do the install.
 
 If no parameters are passed, the parameters from the previous release will be used. If '--set' or '--parameters'
 are specified, the parameters there will be used (even if the resolved set is empty).
+
+With '--diff', the changes to the bundle version, images, parameters and required credentials are shown
+first, and you are asked to confirm them unless '--yes' is set. See 'duffle diff' to only show the changes.
 `
 
 var ErrBundleAndBundleFile = errors.New("Both --bundle and --bundle-file flags cannot be set")
@@ -39,10 +46,22 @@ type upgradeCmd struct {
 	setFiles          []string
 	credentialsFiles  []string
 	relocationMapping string
+	dryRun            bool
+	outputDir         string
+	control           operationControl
+	diff              bool
+	yes               bool
+
+	// params are parameter values set by other commands, such as duffle stack. If they are set, the parameters are
+	// replaced, as with --set.
+	params map[string]interface{}
+
+	// confirm asks whether to go ahead with the upgrade after showing the diff.
+	confirm func(message string) (bool, error)
 }
 
 func newUpgradeCmd(w io.Writer) *cobra.Command {
-	upgrade := &upgradeCmd{out: w}
+	upgrade := &upgradeCmd{out: w, confirm: surveyConfirm}
 
 	cmd := &cobra.Command{
 		Use:   "upgrade [NAME]",
@@ -64,10 +83,15 @@ func newUpgradeCmd(w io.Writer) *cobra.Command {
 	flags.StringVarP(&upgrade.bundle, "bundle", "b", "", "bundle to use for upgrading")
 	flags.StringVar(&upgrade.bundleFile, "bundle-file", "", "path of the bundle file to use for upgrading")
 	flags.StringVarP(&upgrade.relocationMapping, "relocation-mapping", "m", "", "Path of relocation mapping JSON file")
-	flags.StringArrayVarP(&upgrade.credentialsFiles, "credentials", "c", []string{}, "Specify credentials to use inside the CNAB bundle. This can be a credentialset name or a path to a file.")
-	flags.StringVarP(&upgrade.valuesFile, "parameters", "p", "", "Specify file containing parameters. Formats: toml, MORE SOON")
+	flags.StringArrayVarP(&upgrade.credentialsFiles, "credentials", "c", []string{}, "Specify credentials to use inside the CNAB bundle. This can be a credentialset name or a path to a file. Defaults to the credential sets the installation was installed or last upgraded with.")
+	flags.StringVarP(&upgrade.valuesFile, "parameters", "p", "", "Specify a file containing parameters, or the name of a parameter set. File formats: json, yaml, toml")
 	flags.StringArrayVarP(&upgrade.setParams, "set", "s", []string{}, "Set individual parameters as NAME=VALUE pairs")
 	flags.StringArrayVarP(&upgrade.setFiles, "set-file", "i", []string{}, "Set individual parameters from file content as NAME=SOURCE-PATH pairs")
+	flags.BoolVar(&upgrade.diff, "diff", false, "Show what the upgrade would change and ask for confirmation before upgrading")
+	flags.BoolVarP(&upgrade.yes, "yes", "y", false, "Do not ask for confirmation of the changes shown by --diff")
+	flags.StringVar(&upgrade.outputDir, "output-dir", "", "Write the outputs of the bundle to files in this directory")
+	upgrade.control.addFlags(flags)
+	flags.BoolVar(&upgrade.dryRun, "dry-run", false, "Print the operation and the claim that would be stored, without running the driver")
 
 	return cmd
 }
@@ -83,63 +107,127 @@ func (up *upgradeCmd) setup() error {
 }
 
 func (up *upgradeCmd) run() error {
+	if !up.dryRun {
+		lease, err := lockClaim(up.name, claim.ActionUpgrade)
+		if err != nil {
+			return err
+		}
+		defer unlockClaim(up.out, lease)
+	}
 
-	claim, err := claimStorage().Read(up.name)
+	installed, err := claimStorage().Read(up.name)
 	if err != nil {
 		return fmt.Errorf("%v not found: %v", up.name, err)
 	}
 
-	// If the user specifies a bundle file, override the existing one.
-	if up.bundleFile != "" {
-		bun, tempDir, err := inferAndLoadBundle(up.bundleFile)
-		if err != nil {
-			return err
-		}
-		if tempDir != "" {
-			defer os.RemoveAll(tempDir)
+	c, err := up.proposedClaim(installed)
+	if err != nil {
+		return err
+	}
+
+	if up.diff {
+		diff := diffClaims(installed, c)
+		diff.print(up.out)
+		if !up.yes && !diff.empty() {
+			ok, err := up.confirm(fmt.Sprintf("Upgrade %q?", up.name))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(up.out, "Upgrade cancelled.")
+				return nil
+			}
 		}
-		claim.Bundle = bun
 	}
 
-	if err = claim.Bundle.Validate(); err != nil {
+	driverImpl, err := prepareBundleDriver(up.driver, c.Bundle, up.control)
+	if err != nil {
 		return err
 	}
 
-	driverImpl, err := prepareDriver(up.driver)
+	creds, err := loadCredentials(claimCredentialSets(c, up.credentialsFiles), c.Bundle)
 	if err != nil {
 		return err
 	}
 
-	creds, err := loadCredentials(up.credentialsFiles, claim.Bundle)
+	opRelocator, err := makeOpRelocator(up.relocationMapping)
 	if err != nil {
 		return err
 	}
 
-	// Override parameters only if some are set.
-	if up.valuesFile != "" || len(up.setParams) > 0 {
-		claim.Parameters, err = calculateParamValues(claim.Bundle, up.valuesFile, up.setParams, up.setFiles)
-		if err != nil {
-			return err
+	if up.dryRun {
+		dryRun := &dryRunDriver{Driver: driverImpl}
+		upgr := &action.Upgrade{Driver: dryRun}
+		if err := upgr.Run(&c, creds, setOut(up.out), opRelocator); err != nil {
+			return fmt.Errorf("could not upgrade %q: %s", up.name, err)
 		}
+		return dryRun.printPlan(up.out, &c, creds, claimStore)
 	}
 
-	opRelocator, err := makeOpRelocator(up.relocationMapping)
+	oplog, err := startOperationLog(home.Home(homePath()), &c, claim.ActionUpgrade, up.out, creds)
 	if err != nil {
 		return err
 	}
-
+	controlled, release := up.control.control(driverImpl, oplog)
+	defer release()
 	upgr := &action.Upgrade{
-		Driver: driverImpl,
+		Driver: controlled,
 	}
-	err = upgr.Run(&claim, creds, setOut(up.out), opRelocator)
+	err = upgr.Run(&c, creds, setOut(oplog), opRelocator)
+	recordCancellation(&c, err)
+	finishOperationLog(up.out, oplog, &c)
 
 	// persist the claim, regardless of the success of the upgrade action
-	persistErr := claimStorage().Store(claim)
+	persistErr := claimStorage().Store(c)
 
 	if err != nil {
 		return fmt.Errorf("could not upgrade %q: %s", up.name, err)
 	}
-	return persistErr
+	if persistErr != nil {
+		return persistErr
+	}
+	if up.outputDir != "" {
+		return writeOutputs(up.out, up.outputDir, c)
+	}
+	return nil
+}
+
+// proposedClaim returns the claim of the installation as it would be upgraded: with the new bundle, if one was
+// given, and the new parameters, if any were set.
+func (up *upgradeCmd) proposedClaim(installed claim.Claim) (claim.Claim, error) {
+	c := installed
+
+	// If the user specifies a bundle file, override the existing one.
+	if up.bundleFile != "" {
+		bun, tempDir, err := inferAndLoadBundle(up.bundleFile)
+		if err != nil {
+			return c, err
+		}
+		if tempDir != "" {
+			defer os.RemoveAll(tempDir)
+		}
+		c.Bundle = bun
+	}
+
+	if err := c.Bundle.Validate(); err != nil {
+		return c, err
+	}
+
+	// Override parameters only if some are set.
+	if up.valuesFile != "" || len(up.setParams) > 0 || up.params != nil {
+		vals, err := collectParamValues(c.Bundle, up.valuesFile, up.setParams, up.setFiles)
+		if err != nil {
+			return c, err
+		}
+		if err := mergeParams(vals, up.params, c.Bundle); err != nil {
+			return c, err
+		}
+		if c.Parameters, err = bundle.ValuesOrDefaults(vals, c.Bundle); err != nil {
+			return c, err
+		}
+	}
+	rememberCredentialSets(&c, up.credentialsFiles)
+	return c, nil
 }
 
 func prepareBundleFile(bundle, bundleFile string) (string, error) {
@@ -148,10 +236,7 @@ func prepareBundleFile(bundle, bundleFile string) (string, error) {
 	}
 
 	if bundle != "" {
-		bundleFile, err := getBundleFilepath(bundle, homePath())
-		if err != nil {
-			return bundleFile, err
-		}
+		return getBundleFilepath(bundle, homePath())
 	}
 
 	return bundleFile, nil
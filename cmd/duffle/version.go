@@ -4,22 +4,37 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/cnabio/duffle/pkg/extensions"
 	"github.com/cnabio/duffle/pkg/version"
 
+	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
 )
 
+const versionDesc = `
+Print the current version of the Duffle CLI.
+
+With '--extensions', the CNAB extensions that Duffle supports are listed instead. Duffle refuses
+to run actions of bundles that require extensions that are not listed.
+`
+
 func newVersionCmd(w io.Writer) *cobra.Command {
 	const usage = `print current version of the Duffle CLI`
+	var showExtensions bool
 
 	cmd := &cobra.Command{
 		Use:   "version",
 		Short: usage,
-		Long:  usage,
+		Long:  versionDesc,
 		Run: func(cmd *cobra.Command, args []string) {
+			if showExtensions {
+				showSupportedExtensions(cmd.OutOrStdout())
+				return
+			}
 			showVersion(cmd.OutOrStdout())
 		},
 	}
+	cmd.Flags().BoolVar(&showExtensions, "extensions", false, "list the CNAB extensions that are supported")
 
 	return cmd
 }
@@ -27,3 +42,15 @@ func newVersionCmd(w io.Writer) *cobra.Command {
 func showVersion(out io.Writer) {
 	fmt.Fprintln(out, version.Version)
 }
+
+func showSupportedExtensions(out io.Writer) {
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+
+	table.AddRow("EXTENSION", "DESCRIPTION")
+	for _, e := range extensions.Default.List() {
+		table.AddRow(e.Key, e.Description)
+	}
+	fmt.Fprintln(out, table)
+}
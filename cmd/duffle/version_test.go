@@ -15,3 +15,10 @@ func TestVersion(t *testing.T) {
 	showVersion(buf)
 	assert.Equal(t, version.Version, strings.TrimSpace(buf.String()))
 }
+
+func TestVersionExtensions(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	showSupportedExtensions(buf)
+	assert.Regexp(t, `(?m)^io.cnab.dependencies\s+installs the bundles`, buf.String())
+	assert.Regexp(t, `(?m)^io.cnab.docker\s+`, buf.String())
+}
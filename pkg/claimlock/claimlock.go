@@ -0,0 +1,277 @@
+// Package claimlock provides leases that keep several Duffle processes from running actions on the same
+// installation at the same time.
+//
+// A lease is a record, stored in a Backend, naming its holder and the time it expires. While an action runs, its
+// lease is renewed in the background, so a lease only expires if its holder dies. An expired lease is taken over by
+// the next process that asks for it.
+package claimlock
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long a lease lasts without being renewed.
+const DefaultTTL = 5 * time.Minute
+
+// ErrConflict is returned by Backend.Swap when the record does not hold the expected data.
+var ErrConflict = errors.New("the lock record was changed concurrently")
+
+// ErrLeaseLost is returned by Lease.Release when the lease was broken or taken over while it was held.
+var ErrLeaseLost = errors.New("the lock was broken while it was held")
+
+// Backend stores lease records.
+//
+// Swap must be atomic across all processes using the backend: it is the only way leases are created, renewed and
+// released.
+type Backend interface {
+	// Read returns the record with the given name, or nil if there is none.
+	Read(name string) ([]byte, error)
+	// Swap replaces the record with the given name by new if it currently holds old, and returns ErrConflict
+	// otherwise. A nil old stands for a record that does not exist, and a nil new deletes the record.
+	Swap(name string, old, new []byte) error
+}
+
+// Holder describes the process holding a lease.
+type Holder struct {
+	User   string `json:"user,omitempty"`
+	Host   string `json:"host,omitempty"`
+	PID    int    `json:"pid"`
+	Action string `json:"action,omitempty"`
+}
+
+func (h Holder) String() string {
+	s := fmt.Sprintf("%s@%s (PID %d)", h.User, h.Host, h.PID)
+	if h.Action != "" {
+		s += " running " + h.Action
+	}
+	return s
+}
+
+// Info is the record of a lease.
+type Info struct {
+	Name     string    `json:"name"`
+	ID       string    `json:"id"`
+	Holder   Holder    `json:"holder"`
+	Acquired time.Time `json:"acquired"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Expired reports whether the lease has expired at the given time.
+func (i Info) Expired(now time.Time) bool {
+	return !now.Before(i.Expires)
+}
+
+// LockedError is returned when a lease is held by someone else.
+type LockedError struct {
+	Info Info
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%q is locked by %s since %s, until %s", e.Info.Name, e.Info.Holder,
+		e.Info.Acquired.Format(time.RFC3339), e.Info.Expires.Format(time.RFC3339))
+}
+
+// Locker hands out leases stored in a backend.
+type Locker struct {
+	backend Backend
+	// TTL is how long a lease lasts without being renewed.
+	TTL time.Duration
+	// now returns the current time.
+	now func() time.Time
+}
+
+// New returns a Locker storing leases in backend.
+func New(backend Backend) *Locker {
+	return &Locker{backend: backend, TTL: DefaultTTL, now: time.Now}
+}
+
+// Read returns the lease with the given name, or nil if there is none. Expired leases are returned too.
+func (l *Locker) Read(name string) (*Info, error) {
+	data, err := l.backend.Read(name)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	info := &Info{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("lock %q is corrupt: %v", name, err)
+	}
+	return info, nil
+}
+
+// Acquire takes the lease with the given name for the named action, taking over the lease if it has expired. If the
+// lease is held by someone else, a *LockedError is returned.
+//
+// The lease is renewed in the background until it is released.
+func (l *Locker) Acquire(name, action string) (*Lease, error) {
+	for {
+		old, err := l.backend.Read(name)
+		if err != nil {
+			return nil, err
+		}
+		if old != nil {
+			current := Info{}
+			if err := json.Unmarshal(old, &current); err != nil {
+				return nil, fmt.Errorf("lock %q is corrupt: %v", name, err)
+			}
+			if !current.Expired(l.now()) {
+				return nil, &LockedError{Info: current}
+			}
+		}
+
+		now := l.now()
+		info := Info{Name: name, ID: newID(), Holder: currentHolder(action), Acquired: now, Expires: now.Add(l.TTL)}
+		data, err := json.Marshal(info)
+		if err != nil {
+			return nil, err
+		}
+		err = l.backend.Swap(name, old, data)
+		if err == ErrConflict {
+			// someone else got there first; look again
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		lease := &Lease{locker: l, info: info, data: data, done: make(chan struct{}), stopped: make(chan struct{})}
+		go lease.keepAlive()
+		return lease, nil
+	}
+}
+
+// Break removes the lease with the given name, whoever holds it, and returns it. It returns nil if there was none.
+func (l *Locker) Break(name string) (*Info, error) {
+	for {
+		data, err := l.backend.Read(name)
+		if err != nil || data == nil {
+			return nil, err
+		}
+		info := &Info{}
+		if err := json.Unmarshal(data, info); err != nil {
+			// remove it all the same
+			info = &Info{Name: name}
+		}
+		err = l.backend.Swap(name, data, nil)
+		if err == ErrConflict {
+			continue
+		}
+		return info, err
+	}
+}
+
+// Lease is a held lease.
+type Lease struct {
+	locker *Locker
+
+	mu   sync.Mutex
+	info Info
+	data []byte
+	lost bool
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// Info returns the record of the lease.
+func (l *Lease) Info() Info {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.info
+}
+
+// Release gives the lease up. It returns ErrLeaseLost if the lease was broken or taken over while it was held.
+func (l *Lease) Release() error {
+	close(l.done)
+	<-l.stopped
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lost {
+		return ErrLeaseLost
+	}
+	err := l.locker.backend.Swap(l.info.Name, l.data, nil)
+	if err == ErrConflict {
+		return ErrLeaseLost
+	}
+	return err
+}
+
+// Abandon stops renewing the lease without giving it up, for a process that exits while the action it locked may
+// still be running. The lease stays held until it expires or is broken.
+func (l *Lease) Abandon() {
+	close(l.done)
+	<-l.stopped
+}
+
+// keepAlive renews the lease until it is released or abandoned.
+func (l *Lease) keepAlive() {
+	defer close(l.stopped)
+	ticker := time.NewTicker(l.locker.TTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			if !l.renew() {
+				return
+			}
+		}
+	}
+}
+
+// renew extends the lease, and reports whether it is still held.
+func (l *Lease) renew() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	info := l.info
+	info.Expires = l.locker.now().Add(l.locker.TTL)
+	data, err := json.Marshal(info)
+	if err != nil {
+		return true
+	}
+	switch err := l.locker.backend.Swap(info.Name, l.data, data); {
+	case err == ErrConflict:
+		l.lost = true
+		return false
+	case err != nil:
+		// try again next time; the lease does not expire before then
+		return true
+	}
+	l.info, l.data = info, data
+	return true
+}
+
+// currentHolder describes the current process.
+func currentHolder(action string) Holder {
+	h := Holder{PID: os.Getpid(), Action: action}
+	if u, err := user.Current(); err == nil {
+		h.User = u.Username
+	}
+	h.Host, _ = os.Hostname()
+	return h
+}
+
+// newID returns a random ID, so that leases taken at the same time by the same process are told apart.
+func newID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// equal reports whether two records are the same, where nil stands for a record that does not exist.
+func equal(a, b []byte) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return bytes.Equal(a, b)
+}
@@ -0,0 +1,151 @@
+package claimlock
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testLocker(t *testing.T) (*Locker, func()) {
+	dir, err := ioutil.TempDir("", "claimlock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(NewFileBackend(dir)), func() { os.RemoveAll(dir) }
+}
+
+func TestAcquireRelease(t *testing.T) {
+	is := assert.New(t)
+	l, cleanup := testLocker(t)
+	defer cleanup()
+
+	lease, err := l.Acquire("app", "upgrade")
+	is.NoError(err)
+	is.Equal(os.Getpid(), lease.Info().Holder.PID)
+	is.Equal("upgrade", lease.Info().Holder.Action)
+
+	_, err = l.Acquire("app", "uninstall")
+	is.IsType(&LockedError{}, err)
+	is.Equal(lease.Info().ID, err.(*LockedError).Info.ID)
+
+	other, err := l.Acquire("other", "install")
+	is.NoError(err, "leases on other installations are independent")
+	is.NoError(other.Release())
+
+	is.NoError(lease.Release())
+	info, err := l.Read("app")
+	is.NoError(err)
+	is.Nil(info)
+
+	lease, err = l.Acquire("app", "upgrade")
+	is.NoError(err)
+	is.NoError(lease.Release())
+}
+
+func TestExpiredLeaseIsTakenOver(t *testing.T) {
+	is := assert.New(t)
+	l, cleanup := testLocker(t)
+	defer cleanup()
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	stale, err := l.Acquire("app", "install")
+	is.NoError(err)
+
+	now = now.Add(DefaultTTL)
+	lease, err := l.Acquire("app", "upgrade")
+	is.NoError(err)
+	is.Equal("upgrade", lease.Info().Holder.Action)
+
+	is.Equal(ErrLeaseLost, stale.Release())
+	is.NoError(lease.Release())
+}
+
+func TestBreak(t *testing.T) {
+	is := assert.New(t)
+	l, cleanup := testLocker(t)
+	defer cleanup()
+
+	info, err := l.Break("app")
+	is.NoError(err)
+	is.Nil(info, "there is nothing to break")
+
+	lease, err := l.Acquire("app", "upgrade")
+	is.NoError(err)
+	info, err = l.Break("app")
+	is.NoError(err)
+	is.Equal(lease.Info().ID, info.ID)
+
+	is.Equal(ErrLeaseLost, lease.Release())
+}
+
+func TestLeaseIsRenewed(t *testing.T) {
+	is := assert.New(t)
+	l, cleanup := testLocker(t)
+	defer cleanup()
+	l.TTL = 150 * time.Millisecond
+
+	lease, err := l.Acquire("app", "upgrade")
+	is.NoError(err)
+	first := lease.Info().Expires
+	time.Sleep(3 * l.TTL)
+
+	info, err := l.Read("app")
+	is.NoError(err)
+	is.True(info.Expires.After(first), "the lease is renewed while it is held")
+	is.False(info.Expired(time.Now()))
+
+	_, err = l.Acquire("app", "uninstall")
+	is.IsType(&LockedError{}, err)
+	is.NoError(lease.Release())
+}
+
+func TestOnlyOneHolder(t *testing.T) {
+	is := assert.New(t)
+	l, cleanup := testLocker(t)
+	defer cleanup()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		leases []*Lease
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lease, err := l.Acquire("app", "upgrade")
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			leases = append(leases, lease)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	is.Len(leases, 1)
+	for _, lease := range leases {
+		is.NoError(lease.Release())
+	}
+}
+
+func TestAbandonKeepsLease(t *testing.T) {
+	is := assert.New(t)
+	l, cleanup := testLocker(t)
+	defer cleanup()
+
+	lease, err := l.Acquire("app", "install")
+	is.NoError(err)
+	lease.Abandon()
+
+	_, err = l.Acquire("app", "upgrade")
+	is.IsType(&LockedError{}, err, "an abandoned lease is still held")
+	info, err := l.Read("app")
+	is.NoError(err)
+	is.Equal(lease.Info().ID, info.ID)
+}
@@ -0,0 +1,61 @@
+package claimlock
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cnabio/duffle/pkg/filelock"
+	"github.com/cnabio/duffle/pkg/osutil"
+)
+
+// FileBackend is a Backend that keeps each record in a file in a directory. Swaps are guarded by an advisory lock
+// file next to the record.
+type FileBackend struct {
+	dir string
+}
+
+// NewFileBackend returns a FileBackend storing records in dir, which is created when needed.
+func NewFileBackend(dir string) *FileBackend {
+	return &FileBackend{dir: dir}
+}
+
+// Read returns the record with the given name, or nil if there is none.
+func (b *FileBackend) Read(name string) ([]byte, error) {
+	data, err := ioutil.ReadFile(b.path(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Swap replaces the record with the given name by new if it currently holds old.
+func (b *FileBackend) Swap(name string, old, new []byte) error {
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return err
+	}
+	lock, err := filelock.Acquire(b.path(name)+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	current, err := b.Read(name)
+	if err != nil {
+		return err
+	}
+	if !equal(current, old) {
+		return ErrConflict
+	}
+	if new == nil {
+		if err := os.Remove(b.path(name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return osutil.AtomicWriteFile(b.path(name), new, 0644)
+}
+
+func (b *FileBackend) path(name string) string {
+	return filepath.Join(b.dir, name+".json")
+}
@@ -0,0 +1,157 @@
+// Package claimstore provides the backing store for claims, which keeps the values of sensitive parameters and
+// outputs encrypted at rest.
+package claimstore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/utils/crud"
+
+	"github.com/cnabio/duffle/pkg/crypto/secret"
+)
+
+// Store is a crud.Store for claims that encrypts the values of write-only parameters and outputs before passing a
+// claim on to the backing store, and decrypts them again when the claim is read.
+//
+// The key is read from keyPath, and generated the first time a claim with sensitive values is stored. Claims that
+// were stored before encryption was introduced are read as they are, and encrypted the next time they are stored.
+type Store struct {
+	backing crud.Store
+	keyPath string
+}
+
+// New returns a Store that stores claims in backing, encrypted with the key at keyPath.
+func New(backing crud.Store, keyPath string) *Store {
+	return &Store{backing: backing, keyPath: keyPath}
+}
+
+// List returns the names of all claims.
+func (s *Store) List() ([]string, error) {
+	return s.backing.List()
+}
+
+// Store encrypts the sensitive values of the claim in data and stores it.
+func (s *Store) Store(name string, data []byte) error {
+	var c claim.Claim
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	var key *secret.Key
+	seal := func(val interface{}) (interface{}, error) {
+		if str, ok := val.(string); ok && secret.IsSealed(str) {
+			return val, nil
+		}
+		if key == nil {
+			var err error
+			if key, err = secret.LoadOrCreateKey(s.keyPath); err != nil {
+				return nil, fmt.Errorf("could not load the claim encryption key: %v", err)
+			}
+		}
+		plain, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		return key.Seal(plain)
+	}
+	changed, err := transformSensitive(&c, seal)
+	if err != nil {
+		return fmt.Errorf("could not encrypt claim %q: %v", name, err)
+	}
+	if changed {
+		if data, err = json.MarshalIndent(c, "", "  "); err != nil {
+			return err
+		}
+	}
+	return s.backing.Store(name, data)
+}
+
+// Read reads the claim with the given name and decrypts its sensitive values.
+func (s *Store) Read(name string) ([]byte, error) {
+	data, err := s.backing.Read(name)
+	if err != nil {
+		return nil, err
+	}
+	var c claim.Claim
+	if err := json.Unmarshal(data, &c); err != nil {
+		// leave it to the claim store to report the error
+		return data, nil
+	}
+
+	var key *secret.Key
+	open := func(val interface{}) (interface{}, error) {
+		str, ok := val.(string)
+		if !ok || !secret.IsSealed(str) {
+			return val, nil
+		}
+		if key == nil {
+			var err error
+			if key, err = secret.LoadKey(s.keyPath); err != nil {
+				return nil, fmt.Errorf("could not load the claim encryption key: %v", err)
+			}
+		}
+		plain, err := key.Open(str)
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		err = json.Unmarshal(plain, &v)
+		return v, err
+	}
+	changed, err := transformSensitive(&c, open)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt claim %q: %v", name, err)
+	}
+	if !changed {
+		return data, nil
+	}
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// Delete removes the claim with the given name.
+func (s *Store) Delete(name string) error {
+	return s.backing.Delete(name)
+}
+
+// transformSensitive replaces the values of the write-only parameters and outputs of c with the result of fn, and
+// reports whether there were any.
+func transformSensitive(c *claim.Claim, fn func(interface{}) (interface{}, error)) (bool, error) {
+	if c.Bundle == nil {
+		return false, nil
+	}
+	changed := false
+	for name, val := range c.Parameters {
+		p, ok := c.Bundle.Parameters[name]
+		if !ok || val == nil || !isWriteOnly(c.Bundle, p.Definition) {
+			continue
+		}
+		v, err := fn(val)
+		if err != nil {
+			return false, fmt.Errorf("parameter %q: %v", name, err)
+		}
+		c.Parameters[name] = v
+		changed = true
+	}
+	for name, val := range c.Outputs {
+		o, ok := c.Bundle.Outputs[name]
+		if !ok || val == nil || !isWriteOnly(c.Bundle, o.Definition) {
+			continue
+		}
+		v, err := fn(val)
+		if err != nil {
+			return false, fmt.Errorf("output %q: %v", name, err)
+		}
+		c.Outputs[name] = v
+		changed = true
+	}
+	return changed, nil
+}
+
+// isWriteOnly reports whether the named definition of the bundle marks values as sensitive.
+func isWriteOnly(bun *bundle.Bundle, definition string) bool {
+	schema, ok := bun.Definitions[definition]
+	return ok && schema.WriteOnly != nil && *schema.WriteOnly
+}
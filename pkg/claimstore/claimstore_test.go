@@ -0,0 +1,95 @@
+package claimstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/stretchr/testify/assert"
+)
+
+func testClaim() claim.Claim {
+	writeOnly := true
+	return claim.Claim{
+		Name: "secret",
+		Bundle: &bundle.Bundle{
+			Name:    "secret",
+			Version: "0.1.0",
+			Definitions: definition.Definitions{
+				"string":   {Type: "string"},
+				"password": {Type: "string", WriteOnly: &writeOnly},
+				"pin":      {Type: "integer", WriteOnly: &writeOnly},
+			},
+			Parameters: map[string]bundle.Parameter{
+				"user":     {Definition: "string"},
+				"password": {Definition: "password"},
+				"pin":      {Definition: "pin"},
+			},
+			Outputs: map[string]bundle.Output{
+				"token": {Definition: "password", Path: "/cnab/app/outputs/token"},
+			},
+		},
+		Parameters: map[string]interface{}{"user": "admin", "password": "hunter2", "pin": float64(1234)},
+		Outputs:    map[string]interface{}{"token": "t0k3n"},
+	}
+}
+
+func TestStoreEncryptsSensitiveValues(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "claimstore")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+
+	backing := crud.NewFileSystemStore(filepath.Join(dir, "claims"), "json")
+	keyPath := filepath.Join(dir, "encryption.key")
+	store := claim.NewClaimStore(New(backing, keyPath))
+
+	c := testClaim()
+	is.NoError(store.Store(c))
+
+	raw, err := backing.Read("secret")
+	is.NoError(err)
+	is.Contains(string(raw), `"user": "admin"`)
+	for _, secret := range []string{"hunter2", "1234", "t0k3n"} {
+		is.NotContains(string(raw), secret, "sensitive values must be encrypted at rest")
+	}
+	is.Equal(3, strings.Count(string(raw), "duffle-secret:v1:"))
+	_, err = os.Stat(keyPath)
+	is.NoError(err, "the key is created when it is first needed")
+
+	read, err := store.Read("secret")
+	is.NoError(err)
+	is.Equal(c.Parameters, read.Parameters)
+	is.Equal(c.Outputs, read.Outputs)
+
+	// without the key, the sensitive values cannot be read
+	is.NoError(os.Remove(keyPath))
+	_, err = store.Read("secret")
+	is.Error(err)
+}
+
+func TestStoreReadsPlainClaims(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "claimstore")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+
+	backing := crud.NewFileSystemStore(filepath.Join(dir, "claims"), "json")
+	is.NoError(claim.NewClaimStore(backing).Store(testClaim()))
+
+	store := claim.NewClaimStore(New(backing, filepath.Join(dir, "encryption.key")))
+	read, err := store.Read("secret")
+	is.NoError(err, "claims stored before encryption was introduced can be read")
+	is.Equal("hunter2", read.Parameters["password"])
+
+	nonSensitive := claim.Claim{Name: "plain", Bundle: &bundle.Bundle{Name: "plain"}, Parameters: map[string]interface{}{"a": "b"}}
+	is.NoError(store.Store(nonSensitive))
+	_, err = os.Stat(filepath.Join(dir, "encryption.key"))
+	is.True(os.IsNotExist(err), "no key is needed for claims without sensitive values")
+}
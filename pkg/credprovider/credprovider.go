@@ -0,0 +1,146 @@
+// Package credprovider resolves credentials from external secret stores.
+//
+// A credential in a credential set can name a provider and a key instead of one of the built-in sources:
+//
+//	credentials:
+//	- name: db-password
+//	  source:
+//	    provider: vault
+//	    key: secret/data/db#password
+//
+// Providers are looked up by name in a Registry, which creates them on first use.
+package credprovider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/ghodss/yaml"
+)
+
+// Provider resolves keys to secret values.
+type Provider interface {
+	// Resolve returns the secret stored under key.
+	Resolve(key string) (string, error)
+}
+
+// Factory creates a provider, usually from configuration in the environment.
+type Factory func() (Provider, error)
+
+// Registry maps provider names to providers.
+type Registry struct {
+	mu        sync.Mutex
+	factories map[string]Factory
+	providers map[string]Provider
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: map[string]Factory{}, providers: map[string]Provider{}}
+}
+
+// Default is the registry of the providers built into Duffle.
+var Default = func() *Registry {
+	r := NewRegistry()
+	r.Register("vault", NewVaultFromEnv)
+	r.Register("http", NewHTTPFromEnv)
+	return r
+}()
+
+// Register adds a provider, which is created with factory when it is first looked up.
+func (r *Registry) Register(name string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[name] = factory
+	delete(r.providers, name)
+}
+
+// Lookup returns the named provider, creating it if needed.
+func (r *Registry) Lookup(name string) (Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.providers[name]; ok {
+		return p, nil
+	}
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown credential provider %q (available: %s)", name, strings.Join(r.names(), ", "))
+	}
+	p, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("cannot configure credential provider %q: %v", name, err)
+	}
+	r.providers[name] = p
+	return p, nil
+}
+
+// names returns the names of the registered providers, sorted. The caller must hold r.mu.
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Source is a credential source that refers to a provider.
+type Source struct {
+	Provider string `json:"provider,omitempty"`
+	Key      string `json:"key,omitempty"`
+}
+
+// rawCredentialSet holds the parts of a credential set that credentials.CredentialSet does not know about.
+type rawCredentialSet struct {
+	Credentials []struct {
+		Name   string `json:"name"`
+		Source Source `json:"source"`
+	} `json:"credentials"`
+}
+
+// ParseSources returns the provider sources of the credentials in the YAML of a credential set, by credential name.
+func ParseSources(data []byte) (map[string]Source, error) {
+	raw := rawCredentialSet{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	sources := map[string]Source{}
+	for _, c := range raw.Credentials {
+		if c.Source.Provider == "" && c.Source.Key == "" {
+			continue
+		}
+		if c.Source.Provider == "" || c.Source.Key == "" {
+			return nil, fmt.Errorf("credential %q: a provider source needs both a provider and a key", c.Name)
+		}
+		sources[c.Name] = c.Source
+	}
+	return sources, nil
+}
+
+// ResolveCredentialSet resolves the credentials of cs that refer to a provider, and stores the values as literal
+// values in cs, so that cs.Resolve() returns them. data is the YAML cs was loaded from.
+func (r *Registry) ResolveCredentialSet(data []byte, cs *credentials.CredentialSet) error {
+	sources, err := ParseSources(data)
+	if err != nil {
+		return err
+	}
+	for i, cred := range cs.Credentials {
+		src, ok := sources[cred.Name]
+		if !ok {
+			continue
+		}
+		p, err := r.Lookup(src.Provider)
+		if err != nil {
+			return fmt.Errorf("credential %q: %v", cred.Name, err)
+		}
+		val, err := p.Resolve(src.Key)
+		if err != nil {
+			return fmt.Errorf("credential %q: %v", cred.Name, err)
+		}
+		cs.Credentials[i].Source = credentials.Source{Value: val}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package credprovider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapProvider map[string]string
+
+func (m mapProvider) Resolve(key string) (string, error) {
+	if v, ok := m[key]; ok {
+		return v, nil
+	}
+	return "", errors.New("not found")
+}
+
+const testCredentialSet = `name: test
+credentials:
+- name: password
+  source:
+    provider: fake
+    key: db#password
+- name: user
+  source:
+    value: admin
+`
+
+func TestResolveCredentialSet(t *testing.T) {
+	is := assert.New(t)
+
+	created := 0
+	r := NewRegistry()
+	r.Register("fake", func() (Provider, error) {
+		created++
+		return mapProvider{"db#password": "hunter2"}, nil
+	})
+
+	cs := &credentials.CredentialSet{}
+	is.NoError(yaml.Unmarshal([]byte(testCredentialSet), cs))
+	is.NoError(r.ResolveCredentialSet([]byte(testCredentialSet), cs))
+	res, err := cs.Resolve()
+	is.NoError(err)
+	is.Equal(credentials.Set{"password": "hunter2", "user": "admin"}, res)
+
+	_, err = r.Lookup("fake")
+	is.NoError(err)
+	is.Equal(1, created, "providers are created once")
+}
+
+func TestResolveCredentialSetErrors(t *testing.T) {
+	is := assert.New(t)
+	r := NewRegistry()
+	r.Register("fake", func() (Provider, error) { return mapProvider{}, nil })
+	r.Register("broken", func() (Provider, error) { return nil, errors.New("not configured") })
+
+	for data, expected := range map[string]string{
+		"credentials:\n- name: a\n  source:\n    provider: vault\n    key: x\n":  `credential "a": unknown credential provider "vault" (available: broken, fake)`,
+		"credentials:\n- name: a\n  source:\n    provider: broken\n    key: x\n": `credential "a": cannot configure credential provider "broken": not configured`,
+		"credentials:\n- name: a\n  source:\n    provider: fake\n    key: x\n":   `credential "a": not found`,
+		"credentials:\n- name: a\n  source:\n    provider: fake\n":               `credential "a": a provider source needs both a provider and a key`,
+	} {
+		cs := &credentials.CredentialSet{}
+		is.NoError(yaml.Unmarshal([]byte(data), cs))
+		is.EqualError(r.ResolveCredentialSet([]byte(data), cs), expected)
+	}
+}
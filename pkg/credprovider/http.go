@@ -0,0 +1,65 @@
+package credprovider
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// HTTP resolves secrets from a generic HTTP endpoint.
+//
+// The secret with key KEY is fetched with a GET request to URL/KEY, and the response body, without a trailing newline,
+// is the value of the secret. Slashes in the key separate path segments; each segment is escaped.
+type HTTP struct {
+	// URL is the base URL of the endpoint.
+	URL string
+	// Token is sent as a bearer token, if it is set.
+	Token string
+	// Client sends the requests. If it is nil, a client with a timeout of 30 seconds is used.
+	Client *http.Client
+}
+
+// NewHTTPFromEnv returns an HTTP provider configured with the environment variables DUFFLE_SECRETS_URL and
+// DUFFLE_SECRETS_TOKEN.
+func NewHTTPFromEnv() (Provider, error) {
+	h := &HTTP{
+		URL:   os.Getenv("DUFFLE_SECRETS_URL"),
+		Token: os.Getenv("DUFFLE_SECRETS_TOKEN"),
+	}
+	if h.URL == "" {
+		return nil, errors.New("DUFFLE_SECRETS_URL is not set")
+	}
+	return h, nil
+}
+
+// Resolve returns the secret named by key.
+func (h *HTTP) Resolve(key string) (string, error) {
+	segments := strings.Split(strings.Trim(key, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(h.URL, "/")+"/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if h.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+	resp, err := client(h.Client).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the secrets endpoint returned %s for %s", resp.Status, key)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(body), "\n"), "\r"), nil
+}
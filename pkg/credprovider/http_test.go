@@ -0,0 +1,68 @@
+package credprovider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP(t *testing.T) {
+	is := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/secrets/db/password":
+			fmt.Fprintln(w, "hunter2")
+		case "/secrets/a%20b":
+			fmt.Fprint(w, "spaced")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	h := &HTTP{URL: ts.URL + "/secrets", Token: "t0ken"}
+
+	val, err := h.Resolve("db/password")
+	is.NoError(err)
+	is.Equal("hunter2", val, "the trailing newline is trimmed")
+
+	val, err = h.Resolve("a b")
+	is.NoError(err)
+	is.Equal("spaced", val)
+
+	_, err = h.Resolve("missing")
+	is.EqualError(err, "the secrets endpoint returned 404 Not Found for missing")
+
+	h.Token = ""
+	_, err = h.Resolve("db/password")
+	is.EqualError(err, "the secrets endpoint returned 401 Unauthorized for db/password")
+}
+
+func TestNewHTTPFromEnv(t *testing.T) {
+	is := assert.New(t)
+	defer setenv("DUFFLE_SECRETS_URL", "")()
+	_, err := NewHTTPFromEnv()
+	is.EqualError(err, "DUFFLE_SECRETS_URL is not set")
+}
+
+// setenv sets an environment variable, and returns a function that restores it.
+func setenv(key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
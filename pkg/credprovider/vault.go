@@ -0,0 +1,131 @@
+package credprovider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Vault resolves secrets from the KV secrets engine of HashiCorp Vault, version 1 or 2.
+//
+// Keys have the form PATH#FIELD, for example "secret/data/db#password" for the field password of the secret db in a
+// KV version 2 engine mounted at secret/. The field can be left out if the secret has a single field.
+type Vault struct {
+	// Address is the URL of the Vault server, for example https://vault.example.com:8200.
+	Address string
+	// Token authenticates the requests.
+	Token string
+	// Namespace is the Vault Enterprise namespace, if any.
+	Namespace string
+	// Client sends the requests. If it is nil, a client with a timeout of 30 seconds is used.
+	Client *http.Client
+}
+
+// NewVaultFromEnv returns a Vault provider configured with the environment variables VAULT_ADDR, VAULT_TOKEN and
+// VAULT_NAMESPACE, which are also used by the Vault CLI.
+func NewVaultFromEnv() (Provider, error) {
+	v := &Vault{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+	}
+	if v.Address == "" {
+		return nil, errors.New("VAULT_ADDR is not set")
+	}
+	if v.Token == "" {
+		return nil, errors.New("VAULT_TOKEN is not set")
+	}
+	return v, nil
+}
+
+// Resolve returns the field of the secret named by key.
+func (v *Vault) Resolve(key string) (string, error) {
+	path, field := key, ""
+	if i := strings.LastIndex(key, "#"); i >= 0 {
+		path, field = key[:i], key[i+1:]
+	}
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", fmt.Errorf("invalid Vault key %q: the path is empty", key)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(v.Address, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	resp, err := client(v.Client).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault returned %s for %s%s", resp.Status, path, vaultErrors(body))
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("cannot parse the Vault response for %s: %v", path, err)
+	}
+	data := secret.Data
+	// KV version 2 nests the fields of the secret in data.data, next to data.metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("the secret %s has %d fields; choose one with %s#FIELD", path, len(data), path)
+		}
+		for f := range data {
+			field = f
+		}
+	}
+	val, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("the secret %s has no field %q", path, field)
+	}
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+	// numbers, booleans and objects are passed on as JSON
+	raw, err := json.Marshal(val)
+	return string(raw), err
+}
+
+// vaultErrors returns the error messages of a Vault error response, formatted to be appended to an error message.
+func vaultErrors(body []byte) string {
+	var resp struct {
+		Errors []string `json:"errors"`
+	}
+	if json.Unmarshal(body, &resp) != nil || len(resp.Errors) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(resp.Errors, "; ")
+}
+
+// defaultClient is used by the providers that are not given a client.
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
+func client(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return defaultClient
+}
@@ -0,0 +1,73 @@
+package credprovider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVault(t *testing.T) {
+	is := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/db":
+			fmt.Fprint(w, `{"data":{"data":{"password":"hunter2","port":5432},"metadata":{"version":3}}}`)
+		case "/v1/kv/token":
+			fmt.Fprint(w, `{"data":{"value":"s3cr3t"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+	defer ts.Close()
+
+	v := &Vault{Address: ts.URL + "/", Token: "root"}
+
+	val, err := v.Resolve("secret/data/db#password")
+	is.NoError(err)
+	is.Equal("hunter2", val, "KV version 2")
+
+	val, err = v.Resolve("secret/data/db#port")
+	is.NoError(err)
+	is.Equal("5432", val)
+
+	val, err = v.Resolve("kv/token")
+	is.NoError(err)
+	is.Equal("s3cr3t", val, "KV version 1, with the only field")
+
+	_, err = v.Resolve("secret/data/db")
+	is.EqualError(err, "the secret secret/data/db has 2 fields; choose one with secret/data/db#FIELD")
+
+	_, err = v.Resolve("secret/data/db#user")
+	is.EqualError(err, `the secret secret/data/db has no field "user"`)
+
+	_, err = v.Resolve("secret/data/missing#password")
+	is.EqualError(err, "Vault returned 404 Not Found for secret/data/missing")
+
+	v.Token = "wrong"
+	_, err = v.Resolve("kv/token")
+	is.EqualError(err, "Vault returned 403 Forbidden for kv/token: permission denied")
+}
+
+func TestNewVaultFromEnv(t *testing.T) {
+	is := assert.New(t)
+	defer setenv("VAULT_ADDR", "")()
+	defer setenv("VAULT_TOKEN", "root")()
+	_, err := NewVaultFromEnv()
+	is.EqualError(err, "VAULT_ADDR is not set")
+
+	os.Setenv("VAULT_ADDR", "http://127.0.0.1:8200")
+	p, err := NewVaultFromEnv()
+	is.NoError(err)
+	is.Equal("http://127.0.0.1:8200", p.(*Vault).Address)
+}
@@ -2,36 +2,57 @@ package digest
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"fmt"
+	// register SHA-256 with go-digest
+	_ "crypto/sha256"
 	"io"
+	"regexp"
+	"strings"
+
+	digest "github.com/opencontainers/go-digest"
 )
 
-// OfReader reads in a stream and spits out a truncated checksum with output similar to `shasum -a 256 build.tar.gz | awk '{print $1}'`.
+// legacyDigest matches the truncated digests that bundles were stored under before full digests were used.
+var legacyDigest = regexp.MustCompile(`^[a-f0-9]{40}$`)
+
+// OfReader reads in a stream and spits out its full SHA-256 digest in the OCI "sha256:<hex>" form, like
+// `echo sha256:$(shasum -a 256 build.tar.gz | awk '{print $1}')`.
 //
 // This is *incredibly* poor on performance as it reads in the entire stream to compute the checksum, and should be used sparingly.
-func OfReader(r io.Reader) (io.Reader, string, error) {
-	// write r to a buffer so we can also write to the sha256 hash.
+func OfReader(r io.Reader) (io.Reader, digest.Digest, error) {
+	// write r to a buffer so we can also write to the digester.
 	buf := new(bytes.Buffer)
-	h := sha256.New()
-	w := io.MultiWriter(buf, h)
-	if _, err := io.Copy(w, r); err != nil {
+	d, err := digest.Canonical.FromReader(io.TeeReader(r, buf))
+	if err != nil {
 		return nil, "", err
 	}
+	return buf, d, nil
+}
 
-	fulltag := h.Sum(nil)
-	tag := fmt.Sprintf("%.20x", fulltag)
-	return buf, tag, nil
+// OfBuffer reads in a byte slice and spits out its full SHA-256 digest in the OCI "sha256:<hex>" form.
+func OfBuffer(b []byte) digest.Digest {
+	return digest.Canonical.FromBytes(b)
 }
 
-// OfBuffer reads in a byte slice and spits out a truncated checksum with output similar to `shasum -a 256 build.tar.gz | awk '{print $1}'`.
-func OfBuffer(b []byte) (string, error) {
-	h := sha256.New()
+// IsLegacy reports whether s is a truncated digest, as produced by earlier versions of Duffle.
+//
+// A legacy digest is the first 20 bytes of the hex-encoded SHA-256 checksum, without an algorithm prefix.
+func IsLegacy(s string) bool {
+	return legacyDigest.MatchString(s)
+}
 
-	if _, err := h.Write(b); err != nil {
-		return "", err
+// Matches reports whether the stored digest is identified by query.
+//
+// The query may be the full digest or the legacy, truncated form of it.
+func Matches(stored, query string) bool {
+	if stored == query {
+		return true
+	}
+	if !IsLegacy(query) {
+		return false
+	}
+	d, err := digest.Parse(stored)
+	if err != nil || d.Algorithm() != digest.SHA256 {
+		return false
 	}
-	fulltag := h.Sum(nil)
-	tag := fmt.Sprintf("%.20x", fulltag)
-	return tag, nil
+	return strings.HasPrefix(d.Encoded(), query)
 }
@@ -6,16 +6,21 @@ import (
 	"testing"
 )
 
+const (
+	testString      = "hello world!"
+	expectedDigest  = "sha256:7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"
+	expectedLegacy  = "7509e5bda0c762d2bac7f90d758b5b2263fa01cc"
+	unrelatedLegacy = "0425467240c734b641673bc2d39433311223ff26"
+)
+
 func TestOfReader(t *testing.T) {
-	testString := "hello world!"
 	buf := bytes.NewBufferString(testString)
-	newBuf, tag, err := OfReader(buf)
+	newBuf, d, err := OfReader(buf)
 	if err != nil {
 		t.Error(err)
 	}
-	expectedTag := "7509e5bda0c762d2bac7f90d758b5b2263fa01cc"
-	if tag != expectedTag {
-		t.Errorf("expected '%s', got '%s'", expectedTag, tag)
+	if d.String() != expectedDigest {
+		t.Errorf("expected '%s', got '%s'", expectedDigest, d)
 	}
 
 	// now check that the buffer we got back hasn't been tampered with
@@ -29,13 +34,41 @@ func TestOfReader(t *testing.T) {
 }
 
 func TestOfBuffer(t *testing.T) {
-	testString := "hello world!"
-	tag, err := OfBuffer([]byte(testString))
-	if err != nil {
-		t.Error(err)
+	d := OfBuffer([]byte(testString))
+	if d.String() != expectedDigest {
+		t.Errorf("expected '%s', got '%s'", expectedDigest, d)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("expected a valid digest, got %v", err)
+	}
+}
+
+func TestIsLegacy(t *testing.T) {
+	if !IsLegacy(expectedLegacy) {
+		t.Errorf("expected %s to be a legacy digest", expectedLegacy)
+	}
+	for _, s := range []string{expectedDigest, "foo-1.0.0.json", expectedLegacy[:39]} {
+		if IsLegacy(s) {
+			t.Errorf("expected %s not to be a legacy digest", s)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		stored, query string
+		want          bool
+	}{
+		{expectedDigest, expectedDigest, true},
+		{expectedDigest, expectedLegacy, true},
+		{expectedDigest, unrelatedLegacy, false},
+		{expectedDigest, expectedLegacy[:10], false},
+		{expectedLegacy, expectedLegacy, true},
+		{"foo-1.0.0.json", expectedLegacy, false},
 	}
-	expectedTag := "7509e5bda0c762d2bac7f90d758b5b2263fa01cc"
-	if tag != expectedTag {
-		t.Errorf("expected '%s', got '%s'", expectedTag, tag)
+	for _, tt := range tests {
+		if got := Matches(tt.stored, tt.query); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.stored, tt.query, got, tt.want)
+		}
 	}
 }
@@ -0,0 +1,146 @@
+// Package secret encrypts small values, such as sensitive claim values or credential sets, with a symmetric key.
+//
+// Values are sealed with NaCl secretbox (XSalsa20 and Poly1305) and encoded as printable strings, so they can take the
+// place of the plain values in JSON documents.
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeySize is the size of a key in bytes.
+const KeySize = 32
+
+// nonceSize is the size of the random nonce prepended to each sealed value.
+const nonceSize = 24
+
+// sealedPrefix marks a string as a sealed value.
+const sealedPrefix = "duffle-secret:v1:"
+
+// ErrDecrypt is returned when a sealed value cannot be opened, either because it was sealed with a different key or
+// because it was modified.
+var ErrDecrypt = errors.New("could not decrypt the value: wrong key or corrupt data")
+
+// Key is a symmetric encryption key.
+type Key [KeySize]byte
+
+// SaltSize is the size of the salts generated by NewSalt.
+const SaltSize = 16
+
+// GenerateKey returns a new random key.
+func GenerateKey() (*Key, error) {
+	k := new(Key)
+	if _, err := io.ReadFull(rand.Reader, k[:]); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// NewSalt returns a random salt for KeyFromPassphrase.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// KeyFromPassphrase derives a key from a passphrase and a salt with scrypt.
+func KeyFromPassphrase(passphrase string, salt []byte) (*Key, error) {
+	raw, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, KeySize)
+	if err != nil {
+		return nil, err
+	}
+	k := new(Key)
+	copy(k[:], raw)
+	return k, nil
+}
+
+// LoadKey reads the hex encoded key at path.
+func LoadKey(path string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(raw) != KeySize {
+		return nil, fmt.Errorf("%s does not contain a valid key", path)
+	}
+	k := new(Key)
+	copy(k[:], raw)
+	return k, nil
+}
+
+// LoadOrCreateKey reads the key at path, generating and saving a new key there if the file does not exist.
+//
+// The key file is only readable by the current user.
+func LoadOrCreateKey(path string) (*Key, error) {
+	k, err := LoadKey(path)
+	if !os.IsNotExist(err) {
+		return k, err
+	}
+
+	if k, err = GenerateKey(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		// another process created the key first
+		return LoadKey(path)
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintln(f, hex.EncodeToString(k[:])); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return k, f.Close()
+}
+
+// Seal encrypts plaintext and returns it as a sealed string.
+func (k *Key) Seal(plaintext []byte) (string, error) {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return "", err
+	}
+	box := secretbox.Seal(nonce[:], plaintext, &nonce, (*[KeySize]byte)(k))
+	return sealedPrefix + base64.StdEncoding.EncodeToString(box), nil
+}
+
+// Open decrypts a string returned by Seal.
+func (k *Key) Open(sealed string) ([]byte, error) {
+	if !IsSealed(sealed) {
+		return nil, errors.New("the value is not encrypted")
+	}
+	box, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedPrefix))
+	if err != nil || len(box) < nonceSize {
+		return nil, ErrDecrypt
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], box[:nonceSize])
+	plaintext, ok := secretbox.Open(nil, box[nonceSize:], &nonce, (*[KeySize]byte)(k))
+	if !ok {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// IsSealed reports whether s is a value returned by Seal.
+func IsSealed(s string) bool {
+	return strings.HasPrefix(s, sealedPrefix)
+}
@@ -0,0 +1,81 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSealOpen(t *testing.T) {
+	is := assert.New(t)
+
+	k, err := GenerateKey()
+	is.NoError(err)
+	sealed, err := k.Seal([]byte("hunter2"))
+	is.NoError(err)
+	is.True(IsSealed(sealed))
+	is.NotContains(sealed, "hunter2")
+
+	plain, err := k.Open(sealed)
+	is.NoError(err)
+	is.Equal("hunter2", string(plain))
+
+	other, err := GenerateKey()
+	is.NoError(err)
+	_, err = other.Open(sealed)
+	is.Equal(ErrDecrypt, err)
+
+	_, err = k.Open(sealed[:len(sealed)-4] + "AAAA")
+	is.Equal(ErrDecrypt, err)
+	is.False(IsSealed("hunter2"))
+}
+
+func TestLoadOrCreateKey(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "secret")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "home", "encryption.key")
+	_, err = LoadKey(path)
+	is.True(os.IsNotExist(err))
+
+	created, err := LoadOrCreateKey(path)
+	is.NoError(err)
+	fi, err := os.Stat(path)
+	is.NoError(err)
+	is.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+	loaded, err := LoadOrCreateKey(path)
+	is.NoError(err)
+	is.Equal(created, loaded)
+
+	is.NoError(ioutil.WriteFile(path, []byte("not a key"), 0600))
+	_, err = LoadKey(path)
+	is.Error(err)
+}
+
+func TestKeyFromPassphrase(t *testing.T) {
+	is := assert.New(t)
+
+	salt, err := NewSalt()
+	is.NoError(err)
+	k1, err := KeyFromPassphrase("correct horse", salt)
+	is.NoError(err)
+	k2, err := KeyFromPassphrase("correct horse", salt)
+	is.NoError(err)
+	is.Equal(k1, k2, "the same passphrase and salt give the same key")
+
+	other, err := KeyFromPassphrase("battery staple", salt)
+	is.NoError(err)
+	is.NotEqual(k1, other)
+
+	otherSalt, err := NewSalt()
+	is.NoError(err)
+	salted, err := KeyFromPassphrase("correct horse", otherSalt)
+	is.NoError(err)
+	is.NotEqual(k1, salted)
+}
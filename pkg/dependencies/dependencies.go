@@ -0,0 +1,208 @@
+// Package dependencies implements the io.cnab.dependencies extension, with which a bundle declares the bundles that
+// must be installed before it.
+//
+// The extension is declared in the custom section of a bundle:
+//
+//	"custom": {
+//	  "io.cnab.dependencies": {
+//	    "sequence": ["storage", "mysql"],
+//	    "requires": {
+//	      "storage": {"bundle": "somecloud/blob-storage"},
+//	      "mysql": {"bundle": "somecloud/mysql", "version": {"ranges": ["5.7.x"]}}
+//	    }
+//	  }
+//	}
+package dependencies
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cnabio/cnab-go/bundle"
+
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+// ExtensionKey is the key of the dependencies extension in the custom section and the required extensions of a bundle.
+const ExtensionKey = "io.cnab.dependencies"
+
+// validAlias matches the aliases of dependencies, which become part of the names of their installations.
+var validAlias = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// Dependencies are the dependencies declared by a bundle.
+type Dependencies struct {
+	// Sequence is the order in which the dependencies are installed. If it is empty, they are installed in the order
+	// of their aliases.
+	Sequence []string `json:"sequence,omitempty"`
+	// Requires are the dependencies by alias.
+	Requires map[string]Dependency `json:"requires,omitempty"`
+}
+
+// Dependency is a bundle that must be installed before the bundle that requires it.
+type Dependency struct {
+	// Bundle is the name of the bundle.
+	Bundle string `json:"bundle"`
+	// Version are the versions of the bundle that satisfy the dependency. Any version does if it is not set.
+	Version *Version `json:"version,omitempty"`
+}
+
+// Version is a set of versions.
+type Version struct {
+	// Ranges are semver ranges, such as 5.7.x or >=1.2 <2. A version satisfies any of them.
+	Ranges []string `json:"ranges,omitempty"`
+}
+
+// Read returns the dependencies declared by b, or nil if it declares none.
+func Read(b *bundle.Bundle) (*Dependencies, error) {
+	raw, ok := b.Custom[ExtensionKey]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	deps := &Dependencies{}
+	if err := json.Unmarshal(data, deps); err != nil {
+		return nil, fmt.Errorf("invalid %s extension: %v", ExtensionKey, err)
+	}
+	if err := deps.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s extension: %v", ExtensionKey, err)
+	}
+	return deps, nil
+}
+
+// Validate checks that every dependency names a bundle, and that the sequence lists every dependency exactly once.
+func (d *Dependencies) Validate() error {
+	for alias, dep := range d.Requires {
+		if !validAlias.MatchString(alias) {
+			return fmt.Errorf("invalid alias %q", alias)
+		}
+		if dep.Bundle == "" {
+			return fmt.Errorf("dependency %s does not name a bundle", alias)
+		}
+	}
+	if len(d.Sequence) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, alias := range d.Sequence {
+		if _, ok := d.Requires[alias]; !ok {
+			return fmt.Errorf("the sequence lists %s, which is not a dependency", alias)
+		}
+		if seen[alias] {
+			return fmt.Errorf("the sequence lists %s more than once", alias)
+		}
+		seen[alias] = true
+	}
+	if len(seen) != len(d.Requires) {
+		return errors.New("the sequence must list every dependency")
+	}
+	return nil
+}
+
+// Aliases returns the aliases of the dependencies in the order they are installed in.
+func (d *Dependencies) Aliases() []string {
+	if len(d.Sequence) > 0 {
+		return d.Sequence
+	}
+	aliases := make([]string, 0, len(d.Requires))
+	for alias := range d.Requires {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// Constraint returns the semver constraint the version of the dependency must satisfy, or "" if any version does.
+func (d Dependency) Constraint() string {
+	if d.Version == nil {
+		return ""
+	}
+	return strings.Join(d.Version.Ranges, " || ")
+}
+
+func (d Dependency) String() string {
+	if c := d.Constraint(); c != "" {
+		return fmt.Sprintf("%s (%s)", d.Bundle, c)
+	}
+	return d.Bundle
+}
+
+// Node is a dependency resolved to a bundle in the local bundle store.
+type Node struct {
+	// Alias is the alias of the dependency in the bundle that requires it.
+	Alias string
+	// Dependency is the dependency as declared.
+	Dependency Dependency
+	// Digest is the digest of the bundle the dependency resolved to.
+	Digest string
+	// Bundle is the bundle the dependency resolved to.
+	Bundle *bundle.Bundle
+	// Dependencies are the dependencies of Bundle.
+	Dependencies []*Node
+	// Err tells why the dependency could not be resolved.
+	Err error
+}
+
+// Loader loads the bundle stored under a digest.
+type Loader func(digest string) (*bundle.Bundle, error)
+
+// Resolve resolves the dependencies of b, and their dependencies, to the highest versions in the index that satisfy
+// them. The nodes are returned in the order they are installed in.
+//
+// Dependencies that cannot be resolved are returned with Err set; use Check to find them.
+func Resolve(b *bundle.Bundle, index repo.Index, load Loader) ([]*Node, error) {
+	return resolve(b, index, load, []string{b.Name})
+}
+
+func resolve(b *bundle.Bundle, index repo.Index, load Loader, path []string) ([]*Node, error) {
+	deps, err := Read(b)
+	if err != nil || deps == nil {
+		return nil, err
+	}
+
+	nodes := make([]*Node, 0, len(deps.Requires))
+	for _, alias := range deps.Aliases() {
+		dep := deps.Requires[alias]
+		n := &Node{Alias: alias, Dependency: dep}
+		nodes = append(nodes, n)
+
+		for _, name := range path {
+			if name == dep.Bundle {
+				n.Err = fmt.Errorf("dependency cycle: %s -> %s", strings.Join(path, " -> "), dep.Bundle)
+			}
+		}
+		if n.Err != nil {
+			continue
+		}
+		if n.Digest, n.Err = index.Get(dep.Bundle, dep.Constraint()); n.Err != nil {
+			n.Err = fmt.Errorf("no version of %s in the local bundle store: %v", dep, n.Err)
+			continue
+		}
+		if n.Bundle, n.Err = load(n.Digest); n.Err != nil {
+			continue
+		}
+		sub := append(append([]string{}, path...), dep.Bundle)
+		n.Dependencies, n.Err = resolve(n.Bundle, index, load, sub)
+	}
+	return nodes, nil
+}
+
+// Check returns an error telling why the first dependency that could not be resolved failed, or nil if all of them
+// were resolved.
+func Check(nodes []*Node) error {
+	for _, n := range nodes {
+		if n.Err != nil {
+			return fmt.Errorf("dependency %s: %v", n.Alias, n.Err)
+		}
+		if err := Check(n.Dependencies); err != nil {
+			return fmt.Errorf("dependency %s: %v", n.Alias, err)
+		}
+	}
+	return nil
+}
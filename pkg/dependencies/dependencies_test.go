@@ -0,0 +1,105 @@
+package dependencies
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/repo"
+)
+
+func withDependencies(name, version string, deps map[string]interface{}) *bundle.Bundle {
+	b := &bundle.Bundle{Name: name, Version: version}
+	if deps != nil {
+		b.Custom = map[string]interface{}{ExtensionKey: deps}
+	}
+	return b
+}
+
+func TestRead(t *testing.T) {
+	is := assert.New(t)
+
+	deps, err := Read(withDependencies("app", "1.0.0", nil))
+	is.NoError(err)
+	is.Nil(deps)
+
+	deps, err = Read(withDependencies("app", "1.0.0", map[string]interface{}{
+		"requires": map[string]interface{}{
+			"mysql":   map[string]interface{}{"bundle": "somecloud/mysql", "version": map[string]interface{}{"ranges": []string{"5.7.x", ">=8.0.1"}}},
+			"storage": map[string]interface{}{"bundle": "somecloud/blob-storage"},
+		},
+	}))
+	is.NoError(err)
+	is.Equal([]string{"mysql", "storage"}, deps.Aliases())
+	is.Equal("5.7.x || >=8.0.1", deps.Requires["mysql"].Constraint())
+	is.Equal("", deps.Requires["storage"].Constraint())
+
+	for name, ext := range map[string]map[string]interface{}{
+		"dependency storage does not name a bundle": {
+			"requires": map[string]interface{}{"storage": map[string]interface{}{}},
+		},
+		"the sequence lists mysql, which is not a dependency": {
+			"sequence": []string{"mysql"},
+			"requires": map[string]interface{}{"storage": map[string]interface{}{"bundle": "blob"}},
+		},
+		"the sequence must list every dependency": {
+			"sequence": []string{"storage"},
+			"requires": map[string]interface{}{"storage": map[string]interface{}{"bundle": "blob"}, "mysql": map[string]interface{}{"bundle": "mysql"}},
+		},
+	} {
+		_, err := Read(withDependencies("app", "1.0.0", ext))
+		is.EqualError(err, "invalid io.cnab.dependencies extension: "+name)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	is := assert.New(t)
+
+	bundles := map[string]*bundle.Bundle{
+		"mysql-5.7.1": withDependencies("mysql", "5.7.1", nil),
+		"mysql-5.7.3": withDependencies("mysql", "5.7.3", map[string]interface{}{
+			"requires": map[string]interface{}{"disk": map[string]interface{}{"bundle": "disk"}},
+		}),
+		"mysql-8.0.0": withDependencies("mysql", "8.0.0", nil),
+		"disk-1.0.0":  withDependencies("disk", "1.0.0", nil),
+	}
+	index := repo.Index{}
+	for digest, b := range bundles {
+		index.Add(b.Name, b.Version, digest)
+	}
+	load := func(digest string) (*bundle.Bundle, error) {
+		if b, ok := bundles[digest]; ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("no bundle %s", digest)
+	}
+
+	app := withDependencies("app", "1.0.0", map[string]interface{}{
+		"sequence": []string{"db", "cache"},
+		"requires": map[string]interface{}{
+			"db":    map[string]interface{}{"bundle": "mysql", "version": map[string]interface{}{"ranges": []string{"5.7.x"}}},
+			"cache": map[string]interface{}{"bundle": "redis"},
+		},
+	})
+	nodes, err := Resolve(app, index, load)
+	is.NoError(err)
+	is.Len(nodes, 2)
+	is.Equal("db", nodes[0].Alias)
+	is.Equal("mysql-5.7.3", nodes[0].Digest, "the highest version in the range is used")
+	is.NoError(nodes[0].Err)
+	is.Len(nodes[0].Dependencies, 1)
+	is.Equal("disk-1.0.0", nodes[0].Dependencies[0].Digest)
+	is.Equal("cache", nodes[1].Alias)
+	is.Error(nodes[1].Err)
+	is.EqualError(Check(nodes), "dependency cache: no version of redis in the local bundle store: no bundle name found")
+
+	// a bundle that depends on itself through its dependencies is a cycle
+	bundles["disk-1.0.0"] = withDependencies("disk", "1.0.0", map[string]interface{}{
+		"requires": map[string]interface{}{"db": map[string]interface{}{"bundle": "mysql"}},
+	})
+	nodes, err = Resolve(bundles["mysql-5.7.3"], index, load)
+	is.NoError(err)
+	is.EqualError(Check(nodes), "dependency disk: dependency db: dependency cycle: mysql -> disk -> mysql")
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 // HomeEnvVar is the env var name that points to Duffle home.
@@ -30,6 +31,17 @@ func (h Home) Bundles() string {
 	return h.Path("bundles")
 }
 
+// BundleFile returns the path of the stored bundle with the given digest.
+//
+// Bundles keyed by a full "algorithm:encoded" digest are stored beneath a directory named after the algorithm,
+// as in an OCI image layout. Bundles keyed by a legacy digest are stored directly in Bundles().
+func (h Home) BundleFile(digest string) string {
+	if parts := strings.SplitN(digest, ":", 2); len(parts) == 2 {
+		return h.Path("bundles", parts[0], parts[1])
+	}
+	return h.Path("bundles", digest)
+}
+
 // Logs returns the path to the Duffle logs.
 func (h Home) Logs() string {
 	return h.Path("logs")
@@ -40,16 +52,36 @@ func (h Home) Claims() string {
 	return h.Path("claims")
 }
 
+// Locks is where locks on claims are stored when the filesystem driver is used.
+func (h Home) Locks() string {
+	return h.Path("locks")
+}
+
+// Stacks is where the records of the installed stacks are stored.
+func (h Home) Stacks() string {
+	return h.Path("stacks")
+}
+
 // Credentials are where credentialsets are stored.
 func (h Home) Credentials() string {
 	return h.Path("credentials")
 }
 
+// Parameters are where parametersets are stored.
+func (h Home) Parameters() string {
+	return h.Path("parameters")
+}
+
 // Repositories returns the path to the file containing information on all downloaded bundles.
 func (h Home) Repositories() string {
 	return h.Path("repositories.json")
 }
 
+// EncryptionKey returns the path to the key that sensitive values in claims are encrypted with.
+func (h Home) EncryptionKey() string {
+	return h.Path("encryption.key")
+}
+
 // SecretKeyRing returns the path to the keyring containing private keys.
 func (h Home) SecretKeyRing() string {
 	return h.Path("secret.ring")
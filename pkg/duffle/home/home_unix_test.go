@@ -19,8 +19,14 @@ func TestDuffleHome(t *testing.T) {
 	is.Equal(ph.Plugins(), "/r/plugins", runtime)
 	is.Equal(ph.Claims(), "/r/claims", runtime)
 	is.Equal(ph.Credentials(), "/r/credentials", runtime)
+	is.Equal(ph.Parameters(), "/r/parameters", runtime)
 	is.Equal(ph.Logs(), "/r/logs", runtime)
+	is.Equal(ph.EncryptionKey(), "/r/encryption.key", runtime)
+	is.Equal(ph.Locks(), "/r/locks", runtime)
+	is.Equal(ph.Stacks(), "/r/stacks", runtime)
 	is.Equal(ph.Repositories(), "/r/repositories.json", runtime)
 	is.Equal(ph.SecretKeyRing(), "/r/secret.ring", runtime)
 	is.Equal(ph.PublicKeyRing(), "/r/public.ring", runtime)
+	is.Equal(ph.BundleFile("sha256:abc123"), "/r/bundles/sha256/abc123", runtime)
+	is.Equal(ph.BundleFile("abc123"), "/r/bundles/abc123", runtime)
 }
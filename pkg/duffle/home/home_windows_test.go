@@ -16,8 +16,13 @@ func TestDuffleHome(t *testing.T) {
 	is.Equal(ph.Bundles(), "r:\\bundles")
 	is.Equal(ph.Claims(), "r:\\claims")
 	is.Equal(ph.Credentials(), "r:\\credentials")
+	is.Equal(ph.Parameters(), "r:\\parameters")
 	is.Equal(ph.Logs(), "r:\\logs")
+	is.Equal(ph.EncryptionKey(), "r:\\encryption.key")
+	is.Equal(ph.Locks(), "r:\\locks")
+	is.Equal(ph.Stacks(), "r:\\stacks")
 	is.Equal(ph.Repositories(), "r:\\repositories.json")
 	is.Equal(ph.SecretKeyRing(), "r:\\secret.ring")
 	is.Equal(ph.PublicKeyRing(), "r:\\public.ring")
+	is.Equal(ph.BundleFile("sha256:abc123"), "r:\\bundles\\sha256\\abc123")
 }
@@ -0,0 +1,80 @@
+package manifest
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/pivotal/image-relocation/pkg/image"
+)
+
+// AddImages adds the given image references to the manifest's images.
+//
+// An image that is already listed is left alone. An image whose repository is already listed under a different tag
+// or digest updates that entry in place, dropping its recorded content digest and size. Any other image is added under a name derived from the last path component
+// of its repository. The names of the added or updated entries are returned in sorted order.
+func (m *Manifest) AddImages(refs []string) ([]string, error) {
+	if m.Images == nil {
+		m.Images = map[string]bundle.Image{}
+	}
+
+	changed := []string{}
+	for _, ref := range refs {
+		named, err := image.NewName(ref)
+		if err != nil {
+			return changed, err
+		}
+
+		name, exists := m.imageByRepository(named.Name())
+		if exists {
+			if m.Images[name].Image == ref {
+				continue
+			}
+			img := m.Images[name]
+			img.Image = ref
+			// the recorded digest and size describe the previous image
+			img.Digest = ""
+			img.Size = 0
+			m.Images[name] = img
+			changed = append(changed, name)
+			continue
+		}
+
+		name = m.uniqueImageName(path.Base(named.Path()))
+		m.Images[name] = bundle.Image{
+			BaseImage: bundle.BaseImage{
+				Image:     ref,
+				ImageType: "docker",
+			},
+		}
+		changed = append(changed, name)
+	}
+
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// imageByRepository returns the name of the image entry pointing at the given (normalized) repository.
+func (m *Manifest) imageByRepository(repo string) (string, bool) {
+	for name, img := range m.Images {
+		named, err := image.NewName(img.Image)
+		if err != nil {
+			continue
+		}
+		if named.Name() == repo {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (m *Manifest) uniqueImageName(base string) string {
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := m.Images[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
@@ -129,3 +129,28 @@ func TestExamples(t *testing.T) {
 		})
 	}
 }
+
+func TestAddImages(t *testing.T) {
+	is := assert.New(t)
+
+	m, err := Load("", "testdata")
+	is.NoError(err)
+
+	changed, err := m.AddImages([]string{
+		"docker.io/istio/citadel:1.0.2",
+		"istio/citadel:1.1.0",
+		"nginx:1.17",
+		"example.com/nginx:1.0",
+	})
+	is.NoError(err)
+	is.Equal([]string{"istio", "nginx", "nginx-2"}, changed)
+
+	is.Equal("istio/citadel:1.1.0", m.Images["istio"].Image)
+	is.Equal("istio images", m.Images["istio"].Description)
+	is.Equal("nginx:1.17", m.Images["nginx"].Image)
+	is.Equal("docker", m.Images["nginx"].ImageType)
+	is.Equal("example.com/nginx:1.0", m.Images["nginx-2"].Image)
+
+	_, err = m.AddImages([]string{"NOT A REFERENCE"})
+	is.Error(err)
+}
@@ -0,0 +1,78 @@
+package extensions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/driver"
+	"github.com/cnabio/cnab-go/driver/docker"
+	"github.com/docker/docker/api/types/container"
+)
+
+// DockerKey is the key of the extension with which a bundle tells how the docker driver should run its invocation
+// image.
+const DockerKey = "io.cnab.docker"
+
+// DockerOptions are the options of the docker extension, as in
+//
+//	"custom": {"io.cnab.docker": {"privileged": true}}
+type DockerOptions struct {
+	// Privileged runs the invocation image in a privileged container.
+	Privileged bool `json:"privileged,omitempty"`
+}
+
+// Docker is the extension that applies the docker options of a bundle to the docker driver. Other drivers ignore them.
+var Docker = Extension{
+	Key:         DockerKey,
+	Description: "runs the invocation image in a privileged container with the docker driver, if the bundle asks for it and the user allows it",
+	Validate: func(b *bundle.Bundle) error {
+		_, err := ReadDockerOptions(b)
+		return err
+	},
+	Configure: func(d driver.Driver, b *bundle.Bundle, opts Options) error {
+		dd, ok := d.(*docker.Driver)
+		if !ok {
+			return nil
+		}
+		dockerOpts, err := ReadDockerOptions(b)
+		if err != nil || !dockerOpts.Privileged {
+			return err
+		}
+		if !opts.AllowPrivileged {
+			return &PrivilegedError{Bundle: b.Name}
+		}
+		dd.AddConfigurationOptions(func(_ *container.Config, hostCfg *container.HostConfig) error {
+			hostCfg.Privileged = true
+			return nil
+		})
+		return nil
+	},
+}
+
+// PrivilegedError is returned when a bundle asks to run its invocation image in a privileged container, which the user
+// has not allowed. A privileged container has root access to the docker host.
+type PrivilegedError struct {
+	Bundle string
+}
+
+func (e *PrivilegedError) Error() string {
+	return fmt.Sprintf("bundle %s asks to run its invocation image in a privileged container, which has root access to the docker host", e.Bundle)
+}
+
+// ReadDockerOptions returns the docker options of b.
+func ReadDockerOptions(b *bundle.Bundle) (DockerOptions, error) {
+	var opts DockerOptions
+	raw, ok := b.Custom[DockerKey]
+	if !ok {
+		return opts, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return opts, err
+	}
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return opts, fmt.Errorf("invalid %s extension: %v", DockerKey, err)
+	}
+	return opts, nil
+}
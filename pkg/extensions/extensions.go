@@ -0,0 +1,153 @@
+// Package extensions keeps the registry of the CNAB extensions that Duffle supports.
+//
+// A bundle declares the extensions it uses in its custom section, and lists the ones it cannot run without in its
+// required extensions. Duffle refuses to run actions of bundles that require extensions missing from the registry,
+// rather than running them without the behaviour they rely on.
+package extensions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/driver"
+
+	"github.com/cnabio/duffle/pkg/dependencies"
+)
+
+// Extension is an extension that Duffle supports.
+type Extension struct {
+	// Key is the key of the extension in the custom section of a bundle, such as io.cnab.dependencies.
+	Key string
+	// Description tells what Duffle does with the extension.
+	Description string
+	// Validate checks the data of the extension in a bundle. It may be nil.
+	Validate func(b *bundle.Bundle) error
+	// Configure configures the driver that runs the invocation image of a bundle that declares the extension. It may
+	// be nil.
+	Configure func(d driver.Driver, b *bundle.Bundle, opts Options) error
+}
+
+// Options are what the user allows extensions to do when configuring a driver.
+type Options struct {
+	// AllowPrivileged allows bundles to run their invocation image with privileges on the host, such as in a
+	// privileged docker container.
+	AllowPrivileged bool
+}
+
+// Registry maps extension keys to the extensions that handle them.
+type Registry struct {
+	mu         sync.Mutex
+	extensions map[string]Extension
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{extensions: map[string]Extension{}}
+}
+
+// Default is the registry of the extensions built into Duffle.
+var Default = func() *Registry {
+	r := NewRegistry()
+	r.Register(Extension{
+		Key:         dependencies.ExtensionKey,
+		Description: "installs the bundles a bundle depends on before it, and uninstalls them after it",
+		Validate: func(b *bundle.Bundle) error {
+			_, err := dependencies.Read(b)
+			return err
+		},
+	})
+	r.Register(Docker)
+	return r
+}()
+
+// Register adds an extension, replacing any extension with the same key.
+func (r *Registry) Register(e Extension) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.extensions[e.Key] = e
+}
+
+// Lookup returns the extension with the given key.
+func (r *Registry) Lookup(key string) (Extension, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.extensions[key]
+	return e, ok
+}
+
+// List returns the registered extensions, sorted by key.
+func (r *Registry) List() []Extension {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]Extension, 0, len(r.extensions))
+	for _, e := range r.extensions {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// UnsupportedError is returned for a bundle that requires extensions that are not registered.
+type UnsupportedError struct {
+	Bundle     string
+	Extensions []string
+}
+
+func (e *UnsupportedError) Error() string {
+	noun := "extension"
+	if len(e.Extensions) > 1 {
+		noun = "extensions"
+	}
+	return fmt.Sprintf("bundle %s requires the %s %s, which Duffle does not support", e.Bundle, noun, strings.Join(e.Extensions, ", "))
+}
+
+// Check returns an UnsupportedError if b requires extensions that are not registered, and checks the data of the
+// registered extensions that b declares.
+func (r *Registry) Check(b *bundle.Bundle) error {
+	var unsupported []string
+	for _, key := range b.RequiredExtensions {
+		if _, ok := r.Lookup(key); !ok {
+			unsupported = append(unsupported, key)
+		}
+	}
+	if len(unsupported) > 0 {
+		return &UnsupportedError{Bundle: b.Name, Extensions: unsupported}
+	}
+
+	for _, e := range r.declared(b) {
+		if e.Validate == nil {
+			continue
+		}
+		if err := e.Validate(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Configure configures d for the registered extensions that b declares, within what opts allow.
+func (r *Registry) Configure(d driver.Driver, b *bundle.Bundle, opts Options) error {
+	for _, e := range r.declared(b) {
+		if e.Configure == nil {
+			continue
+		}
+		if err := e.Configure(d, b, opts); err != nil {
+			return fmt.Errorf("extension %s: %w", e.Key, err)
+		}
+	}
+	return nil
+}
+
+// declared returns the registered extensions that b declares in its custom section, sorted by key.
+func (r *Registry) declared(b *bundle.Bundle) []Extension {
+	var declared []Extension
+	for _, e := range r.List() {
+		if _, ok := b.Custom[e.Key]; ok {
+			declared = append(declared, e)
+		}
+	}
+	return declared
+}
@@ -0,0 +1,101 @@
+package extensions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/driver"
+	"github.com/cnabio/cnab-go/driver/docker"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cnabio/duffle/pkg/dependencies"
+)
+
+func TestCheck(t *testing.T) {
+	is := assert.New(t)
+
+	b := &bundle.Bundle{
+		Name:               "app",
+		Custom:             map[string]interface{}{"com.example.a": true, "com.example.b": true, dependencies.ExtensionKey: map[string]interface{}{}},
+		RequiredExtensions: []string{"com.example.a", dependencies.ExtensionKey, "com.example.b"},
+	}
+	err := Default.Check(b)
+	is.EqualError(err, "bundle app requires the extensions com.example.a, com.example.b, which Duffle does not support")
+	unsupported, ok := err.(*UnsupportedError)
+	is.True(ok)
+	is.Equal([]string{"com.example.a", "com.example.b"}, unsupported.Extensions)
+
+	b.RequiredExtensions = []string{dependencies.ExtensionKey}
+	is.NoError(Default.Check(b))
+
+	b.Custom[dependencies.ExtensionKey] = map[string]interface{}{"requires": map[string]interface{}{"db": map[string]interface{}{}}}
+	is.EqualError(Default.Check(b), "invalid io.cnab.dependencies extension: dependency db does not name a bundle")
+
+	b.Custom[DockerKey] = map[string]interface{}{"privileged": "yes"}
+	b.RequiredExtensions = nil
+	delete(b.Custom, dependencies.ExtensionKey)
+	is.Error(Default.Check(b), "extensions that are declared are checked even if they are not required")
+}
+
+func TestConfigure(t *testing.T) {
+	is := assert.New(t)
+
+	var configured []string
+	r := NewRegistry()
+	for _, key := range []string{"com.example.b", "com.example.a", "com.example.unused"} {
+		key := key
+		r.Register(Extension{Key: key, Configure: func(driver.Driver, *bundle.Bundle, Options) error {
+			configured = append(configured, key)
+			return nil
+		}})
+	}
+	r.Register(Extension{Key: "com.example.broken", Configure: func(driver.Driver, *bundle.Bundle, Options) error {
+		return errors.New("broken")
+	}})
+
+	b := &bundle.Bundle{Custom: map[string]interface{}{"com.example.a": nil, "com.example.b": nil}}
+	is.NoError(r.Configure(&driver.DebugDriver{}, b, Options{}))
+	is.Equal([]string{"com.example.a", "com.example.b"}, configured)
+
+	b.Custom["com.example.broken"] = nil
+	is.EqualError(r.Configure(&driver.DebugDriver{}, b, Options{}), "extension com.example.broken: broken")
+
+	keys := []string{}
+	for _, e := range r.List() {
+		keys = append(keys, e.Key)
+	}
+	is.Equal([]string{"com.example.a", "com.example.b", "com.example.broken", "com.example.unused"}, keys)
+}
+
+func TestDockerOptions(t *testing.T) {
+	is := assert.New(t)
+
+	opts, err := ReadDockerOptions(&bundle.Bundle{})
+	is.NoError(err)
+	is.False(opts.Privileged)
+
+	b := &bundle.Bundle{Custom: map[string]interface{}{DockerKey: map[string]interface{}{"privileged": true}}}
+	opts, err = ReadDockerOptions(b)
+	is.NoError(err)
+	is.True(opts.Privileged)
+	is.NoError(Docker.Configure(&driver.DebugDriver{}, b, Options{}), "drivers other than docker ignore the options")
+}
+
+func TestDockerPrivilegedNeedsConsent(t *testing.T) {
+	is := assert.New(t)
+
+	b := &bundle.Bundle{
+		Name:   "privileged",
+		Custom: map[string]interface{}{DockerKey: map[string]interface{}{"privileged": true}},
+	}
+	err := Default.Configure(&docker.Driver{}, b, Options{})
+	var privileged *PrivilegedError
+	is.True(errors.As(err, &privileged), "a privileged bundle is refused unless the user allows it: %v", err)
+	is.Equal("privileged", privileged.Bundle)
+
+	is.NoError(Default.Configure(&docker.Driver{}, b, Options{AllowPrivileged: true}))
+
+	delete(b.Custom, DockerKey)
+	is.NoError(Default.Configure(&docker.Driver{}, b, Options{}), "bundles that are not privileged need no consent")
+}
@@ -0,0 +1,159 @@
+// Package filelock provides advisory, cross-process locks backed by lock files.
+//
+// A lock is held by taking an advisory lock of the operating system on its lock file: flock on Unix and LockFileEx
+// on Windows. The operating system releases it when the holder exits, so a crashed process never leaves a lock
+// behind, and no lock is ever broken by guessing that its holder is gone. For the same reason lock files are never
+// removed: a waiter may still have the file open, and would go on to lock a file that is no longer at path.
+//
+// The holder records its PID and host in the lock file, so that a held lock can be reported.
+package filelock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is how long Acquire waits for a lock held by another process by default.
+const DefaultTimeout = 10 * time.Second
+
+// pollInterval is how often a held lock is tried again while waiting for it.
+const pollInterval = 50 * time.Millisecond
+
+// errLocked is returned by tryAcquire when the lock is held.
+var errLocked = errors.New("lock is held")
+
+// LockedError is returned when a lock could not be acquired because it is held by another process.
+type LockedError struct {
+	// Path is the path of the lock file.
+	Path string
+	// PID is the process ID of the holder, or 0 if it is unknown.
+	PID int
+	// Host is the host the holder runs on, if it is known.
+	Host string
+}
+
+func (e *LockedError) Error() string {
+	switch {
+	case e.PID == 0:
+		return fmt.Sprintf("store is locked (lock file %s)", e.Path)
+	case e.Host == "":
+		return fmt.Sprintf("store is locked by PID %d (lock file %s)", e.PID, e.Path)
+	}
+	return fmt.Sprintf("store is locked by PID %d on %s (lock file %s)", e.PID, e.Host, e.Path)
+}
+
+// holder is the record of the holder of a lock, kept in its lock file.
+type holder struct {
+	PID  int    `json:"pid"`
+	Host string `json:"host,omitempty"`
+}
+
+// Lock is a held lock.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire takes the lock backed by the lock file at path, waiting up to timeout for another holder to release it.
+//
+// If the lock is still held once the timeout expires, a *LockedError is returned.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		l, err := tryAcquire(path)
+		if err != errLocked {
+			return l, err
+		}
+		if time.Now().After(deadline) {
+			h := readHolder(path)
+			return nil, &LockedError{Path: path, PID: h.PID, Host: h.Host}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// tryAcquire takes the lock backed by the lock file at path, or returns errLocked if it is held.
+func tryAcquire(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := lockFile(f)
+	if err != nil || !locked {
+		f.Close()
+		if err == nil {
+			err = errLocked
+		}
+		return nil, err
+	}
+
+	// the lock file may have been removed by hand since it was opened, and another process may hold a new one
+	opened, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	current, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		f.Close()
+		return nil, err
+	}
+	if err != nil || !os.SameFile(opened, current) {
+		f.Close()
+		return tryAcquire(path)
+	}
+
+	if err := recordHolder(f); err != nil {
+		unlockFile(f)
+		f.Close()
+		return nil, err
+	}
+	return &Lock{file: f}, nil
+}
+
+// Release releases the lock. The lock file is left in place.
+func (l *Lock) Release() error {
+	err := l.file.Truncate(0)
+	if uerr := unlockFile(l.file); err == nil {
+		err = uerr
+	}
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// recordHolder records the current process as the holder of the lock file f.
+func recordHolder(f *os.File) error {
+	h := holder{PID: os.Getpid()}
+	h.Host, _ = os.Hostname()
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(data, 0)
+	return err
+}
+
+// readHolder returns the holder recorded in the lock file at path, or a zero holder if it is unknown. Lock files of
+// older versions of Duffle hold only a PID.
+func readHolder(path string) holder {
+	var h holder
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	if json.Unmarshal(data, &h) == nil {
+		return h
+	}
+	h.PID, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	return h
+}
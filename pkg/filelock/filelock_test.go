@@ -0,0 +1,145 @@
+package filelock
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "filelock")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.lock")
+	host, err := os.Hostname()
+	is.NoError(err)
+
+	l, err := Acquire(path, time.Second)
+	is.NoError(err)
+	data, err := ioutil.ReadFile(path)
+	is.NoError(err)
+	var h holder
+	is.NoError(json.Unmarshal(data, &h))
+	is.Equal(holder{PID: os.Getpid(), Host: host}, h)
+
+	_, err = Acquire(path, 100*time.Millisecond)
+	is.Error(err)
+	lerr, ok := err.(*LockedError)
+	is.True(ok)
+	is.Equal(os.Getpid(), lerr.PID)
+	is.Equal(host, lerr.Host)
+	is.Contains(err.Error(), "store is locked by PID "+strconv.Itoa(os.Getpid())+" on "+host)
+
+	is.NoError(l.Release())
+	data, err = ioutil.ReadFile(path)
+	is.NoError(err, "the lock file is left in place")
+	is.Empty(data)
+
+	l, err = Acquire(path, time.Second)
+	is.NoError(err)
+	is.NoError(l.Release())
+}
+
+func TestAcquireIgnoresLeftoverLockFile(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "filelock")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.lock")
+
+	// a lock file is not a lock: this one was left by an older version of Duffle, or by a holder on another host
+	is.NoError(ioutil.WriteFile(path, []byte("2147483646"), 0644))
+	l, err := Acquire(path, 100*time.Millisecond)
+	is.NoError(err)
+	is.NoError(l.Release())
+}
+
+func TestAcquireAfterHolderExits(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "filelock")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.lock")
+
+	l, err := Acquire(path, time.Second)
+	is.NoError(err)
+	// the operating system releases the lock of a process that exits without releasing it
+	is.NoError(l.file.Close())
+
+	l, err = Acquire(path, 100*time.Millisecond)
+	is.NoError(err)
+	is.NoError(l.Release())
+}
+
+func TestLockedErrorReportsHolder(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "filelock")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.lock")
+
+	l, err := Acquire(path, time.Second)
+	is.NoError(err)
+	defer l.Release()
+
+	is.NoError(ioutil.WriteFile(path, []byte(`{"pid":42,"host":"elsewhere"}`), 0644))
+	_, err = Acquire(path, 0)
+	is.Equal(&LockedError{Path: path, PID: 42, Host: "elsewhere"}, err)
+
+	is.NoError(ioutil.WriteFile(path, []byte("42"), 0644))
+	_, err = Acquire(path, 0)
+	is.EqualError(err, "store is locked by PID 42 (lock file "+path+")")
+
+	is.NoError(ioutil.WriteFile(path, nil, 0644))
+	_, err = Acquire(path, 0)
+	is.EqualError(err, "store is locked (lock file "+path+")")
+}
+
+func TestAcquireSerializes(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "filelock")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.lock")
+
+	var (
+		wg      sync.WaitGroup
+		holders int
+		max     int
+		mu      sync.Mutex
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l, err := Acquire(path, DefaultTimeout)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			holders++
+			if holders > max {
+				max = holders
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			holders--
+			mu.Unlock()
+			l.Release()
+		}()
+	}
+	wg.Wait()
+	is.Equal(1, max)
+}
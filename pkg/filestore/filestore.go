@@ -0,0 +1,78 @@
+// Package filestore provides a crud.Store backed by a file system directory that is safe for concurrent use by
+// several processes.
+package filestore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cnabio/cnab-go/utils/crud"
+
+	"github.com/cnabio/duffle/pkg/filelock"
+	"github.com/cnabio/duffle/pkg/osutil"
+)
+
+// Store is a crud.Store where each key is represented by a file in a directory.
+//
+// Records are written atomically, so a crash mid-write never leaves a truncated record behind, and writes and
+// deletions of a record are guarded by an advisory lock file next to it.
+type Store struct {
+	fs crud.Store
+
+	baseDirectory string
+	fileExtension string
+}
+
+// New creates a Store backed by baseDirectory, storing each record in a file with the given extension.
+func New(baseDirectory, fileExtension string) *Store {
+	return &Store{
+		fs:            crud.NewFileSystemStore(baseDirectory, fileExtension),
+		baseDirectory: baseDirectory,
+		fileExtension: fileExtension,
+	}
+}
+
+// List returns the names of all records.
+func (s *Store) List() ([]string, error) {
+	return s.fs.List()
+}
+
+// Read returns the record with the given name, or crud.ErrRecordDoesNotExist.
+func (s *Store) Read(name string) ([]byte, error) {
+	return s.fs.Read(name)
+}
+
+// Store atomically writes the record with the given name.
+func (s *Store) Store(name string, data []byte) error {
+	if err := os.MkdirAll(s.baseDirectory, 0755); err != nil {
+		return err
+	}
+	lock, err := s.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return osutil.AtomicWriteFile(s.fileNameOf(name), data, 0644)
+}
+
+// Delete removes the record with the given name.
+func (s *Store) Delete(name string) error {
+	lock, err := s.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return os.Remove(s.fileNameOf(name))
+}
+
+// Lock takes the advisory lock guarding the record with the given name, waiting for other processes to release it.
+func (s *Store) Lock(name string) (*filelock.Lock, error) {
+	return filelock.Acquire(s.fileNameOf(name)+".lock", filelock.DefaultTimeout)
+}
+
+func (s *Store) fileNameOf(name string) string {
+	return filepath.Join(s.baseDirectory, fmt.Sprintf("%s.%s", name, s.fileExtension))
+}
@@ -0,0 +1,66 @@
+package filestore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "filestore")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+
+	var s crud.Store = New(filepath.Join(dir, "claims"), "json")
+
+	is.NoError(s.Store("foo", []byte(`{"name":"foo"}`)))
+	data, err := s.Read("foo")
+	is.NoError(err)
+	is.Equal(`{"name":"foo"}`, string(data))
+
+	names, err := s.List()
+	is.NoError(err)
+	is.Equal([]string{"foo"}, names)
+
+	is.NoError(s.Delete("foo"))
+	_, err = s.Read("foo")
+	is.Equal(crud.ErrRecordDoesNotExist, err)
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "claims"))
+	is.NoError(err)
+	is.Len(files, 1, "temporary files should be cleaned up")
+	is.Equal("foo.json.lock", files[0].Name(), "lock files are left in place")
+}
+
+func TestStoreConcurrent(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "filestore")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+
+	s := New(dir, "json")
+
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			is.NoError(s.Store("foo", []byte(fmt.Sprintf(`{"revision":%d}`, n))))
+		}(n)
+	}
+	wg.Wait()
+
+	names, err := s.List()
+	is.NoError(err)
+	is.Equal([]string{"foo"}, names)
+	data, err := s.Read("foo")
+	is.NoError(err)
+	is.Regexp(`^\{"revision":\d+\}$`, string(data))
+}
@@ -0,0 +1,178 @@
+// Package imagediscovery finds the container images referenced by Kubernetes manifests and Helm charts.
+package imagediscovery
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// imageLine matches a literal `image:` field on a single line. It is used for files that are not valid YAML,
+// such as unrendered Helm templates.
+var imageLine = regexp.MustCompile(`^\s*(?:-\s+)?image:\s*["']?([^"'\s#]+)["']?\s*(?:#.*)?$`)
+
+// Discover walks dir and returns the sorted, de-duplicated image references found in any YAML file beneath it.
+//
+// Kubernetes manifests are parsed as (multi-document) YAML and every `image` field holding a string is collected.
+// Helm values files are supported through the conventional `image: {registry, repository, tag}` layout. Files that
+// cannot be parsed as YAML, such as Helm templates, are scanned line by line for literal `image:` fields; templated
+// values are skipped because they cannot be resolved without rendering the chart.
+//
+// If dir does not exist, Discover returns no images and no error.
+func Discover(dir string) ([]string, error) {
+	found := map[string]bool{}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return []string{}, nil
+	}
+
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		images, err := fromYAML(data)
+		if err != nil {
+			log.Debugf("%s is not valid YAML, scanning it for literal image fields: %v", path, err)
+			images = fromLines(data)
+		}
+		for _, img := range images {
+			if isImageReference(img) {
+				found[img] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]string, 0, len(found))
+	for img := range found {
+		images = append(images, img)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// fromYAML decodes every document in data and collects the images they reference.
+func fromYAML(data []byte) ([]string, error) {
+	images := []string{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return images, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, walk(doc)...)
+	}
+}
+
+func walk(node interface{}) []string {
+	images := []string{}
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range n {
+			if key, ok := k.(string); ok && key == "image" {
+				switch img := v.(type) {
+				case string:
+					images = append(images, img)
+					continue
+				case map[interface{}]interface{}:
+					if ref := helmImage(img); ref != "" {
+						images = append(images, ref)
+						continue
+					}
+				}
+			}
+			images = append(images, walk(v)...)
+		}
+	case []interface{}:
+		for _, v := range n {
+			images = append(images, walk(v)...)
+		}
+	}
+	return images
+}
+
+// helmImage assembles an image reference from the `registry`, `repository` and `tag` keys commonly used by Helm
+// charts in values.yaml. It returns "" if there is no repository.
+func helmImage(m map[interface{}]interface{}) string {
+	str := func(key string) string {
+		switch v := m[key].(type) {
+		case string:
+			return v
+		case nil:
+			return ""
+		default:
+			// unquoted tags such as 1.17 are decoded as numbers
+			return strings.TrimSpace(yamlScalar(v))
+		}
+	}
+
+	repo := str("repository")
+	if repo == "" {
+		return ""
+	}
+	if registry := str("registry"); registry != "" {
+		repo = registry + "/" + repo
+	}
+	if tag := str("tag"); tag != "" {
+		repo = repo + ":" + tag
+	}
+	return repo
+}
+
+func yamlScalar(v interface{}) string {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// fromLines collects literal image fields from data, one per line.
+func fromLines(data []byte) []string {
+	images := []string{}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		if m := imageLine.FindStringSubmatch(s.Text()); m != nil {
+			images = append(images, m[1])
+		}
+	}
+	return images
+}
+
+// isImageReference reports whether s is a literal, parseable image reference.
+func isImageReference(s string) bool {
+	if s == "" || strings.Contains(s, "{{") || strings.Contains(s, "$") {
+		return false
+	}
+	_, err := image.NewName(s)
+	return err == nil
+}
@@ -0,0 +1,28 @@
+package imagediscovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscover(t *testing.T) {
+	is := assert.New(t)
+
+	images, err := Discover("testdata/app")
+	is.NoError(err)
+	is.Equal([]string{
+		"busybox:1.31",
+		"example.com/acme/api:1.2.0",
+		"example.com/acme/migrate:1.2.0",
+		"nginx:1.17",
+		"prom/nginx-exporter:0.4.2",
+		"quay.io/acme/proxy:v2",
+	}, images)
+}
+
+func TestDiscoverMissingDirectory(t *testing.T) {
+	images, err := Discover("testdata/no-such-dir")
+	assert.NoError(t, err)
+	assert.Empty(t, images)
+}
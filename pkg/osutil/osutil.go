@@ -2,7 +2,9 @@ package osutil
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 // Exists returns whether the given file or directory exists or not.
@@ -45,3 +47,34 @@ func EnsureFile(file string) error {
 
 	return nil
 }
+
+// AtomicWriteFile writes data to a file named by filename, like ioutil.WriteFile, but without ever leaving a
+// partially written file behind.
+//
+// The data is written to a temporary file in the same directory, synced to disk and then renamed over filename.
+func AtomicWriteFile(filename string, data []byte, perm os.FileMode) (err error) {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
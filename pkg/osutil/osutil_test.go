@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -106,3 +107,37 @@ func TestEnsureFileOnDirectory(t *testing.T) {
 		t.Errorf("expected an error when calling EnsureFile() on a directory that exists, got %v", err)
 	}
 }
+
+func TestAtomicWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "index.json")
+	for _, content := range []string{"first", "second"} {
+		if err := AtomicWriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("expected no error writing %s, got %v", name, err)
+		}
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("expected %q, got %q", content, string(data))
+		}
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected no temporary files to be left behind, got %d files", len(files))
+	}
+
+	if err := AtomicWriteFile(filepath.Join(dir, "missing", "index.json"), []byte("x"), 0644); err == nil {
+		t.Error("expected an error writing into a directory that does not exist")
+	}
+}
@@ -0,0 +1,98 @@
+// Package parameters provides named, reusable sets of parameter values.
+//
+// Parameter sets mirror credential sets: each parameter names a source on the local system from which its value
+// is read when the set is used.
+package parameters
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Set is an actual set of resolved parameter values.
+// This is the output of resolving a parameterset file.
+type Set map[string]string
+
+// ParameterSet represents a collection of parameters.
+type ParameterSet struct {
+	// Name is the name of the parameterset.
+	Name string `json:"name" yaml:"name"`
+	// Parameters is a list of parameter specs.
+	Parameters []ParameterStrategy `json:"parameters" yaml:"parameters"`
+}
+
+// ParameterStrategy represents a parameter and the source from which its value is loaded.
+type ParameterStrategy struct {
+	// Name is the name of the parameter.
+	// Name is used to match a parameter strategy to a bundle's parameter.
+	Name string `json:"name" yaml:"name"`
+	// Source is the location of the parameter value.
+	Source Source `json:"source,omitempty" yaml:"source,omitempty"`
+}
+
+// Source represents a strategy for loading a parameter value from the local host.
+type Source struct {
+	Path    string `json:"path,omitempty" yaml:"path,omitempty"`
+	Command string `json:"command,omitempty" yaml:"command,omitempty"`
+	Value   string `json:"value,omitempty" yaml:"value,omitempty"`
+	EnvVar  string `json:"env,omitempty" yaml:"env,omitempty"`
+}
+
+// Load a ParameterSet from a file at a given path.
+//
+// It does not resolve the individual parameters.
+func Load(path string) (*ParameterSet, error) {
+	pset := &ParameterSet{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return pset, err
+	}
+	return pset, yaml.Unmarshal(data, pset)
+}
+
+// Resolve looks up the value of each parameter as described by its Source.
+//
+// Sources take precedence in the following order: command, path, environment variable, value. If the environment
+// variable is not set, the literal value is used instead.
+func (p *ParameterSet) Resolve() (Set, error) {
+	res := make(Set, len(p.Parameters))
+	for _, param := range p.Parameters {
+		src := param.Source
+		var val string
+		switch {
+		case src.Command != "":
+			data, err := execCmd(src.Command)
+			if err != nil {
+				return res, fmt.Errorf("parameter %q: %s", param.Name, err)
+			}
+			val = strings.TrimRight(string(data), "\r\n")
+		case src.Path != "":
+			data, err := ioutil.ReadFile(os.ExpandEnv(src.Path))
+			if err != nil {
+				return res, fmt.Errorf("parameter %q: %s", param.Name, err)
+			}
+			val = string(data)
+		case src.EnvVar != "":
+			var ok bool
+			val, ok = os.LookupEnv(src.EnvVar)
+			if ok {
+				break
+			}
+			fallthrough
+		default:
+			val = src.Value
+		}
+		res[param.Name] = val
+	}
+	return res, nil
+}
+
+func execCmd(cmd string) ([]byte, error) {
+	parts := strings.Split(cmd, " ")
+	return exec.Command(parts[0], parts[1:]...).Output()
+}
@@ -0,0 +1,71 @@
+package parameters
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadAndResolve(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "duffle-parameters")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+
+	valueFile := filepath.Join(dir, "replicas")
+	is.NoError(ioutil.WriteFile(valueFile, []byte("3"), 0644))
+	os.Setenv("DUFFLE_TEST_REGION", "westus")
+	defer os.Unsetenv("DUFFLE_TEST_REGION")
+
+	pset := []byte(`name: prod
+parameters:
+  - name: literal
+    source:
+      value: hello
+  - name: region
+    source:
+      env: DUFFLE_TEST_REGION
+  - name: fallback
+    source:
+      env: DUFFLE_TEST_UNSET
+      value: default
+  - name: replicas
+    source:
+      path: ` + valueFile + `
+  - name: command
+    source:
+      command: echo world
+`)
+	path := filepath.Join(dir, "prod.yaml")
+	is.NoError(ioutil.WriteFile(path, pset, 0644))
+
+	ps, err := Load(path)
+	is.NoError(err)
+	is.Equal("prod", ps.Name)
+	is.Len(ps.Parameters, 5)
+
+	set, err := ps.Resolve()
+	is.NoError(err)
+	is.Equal(Set{
+		"literal":  "hello",
+		"region":   "westus",
+		"fallback": "default",
+		"replicas": "3",
+		"command":  "world",
+	}, set)
+}
+
+func TestResolveMissingFile(t *testing.T) {
+	ps := &ParameterSet{
+		Name: "broken",
+		Parameters: []ParameterStrategy{
+			{Name: "missing", Source: Source{Path: "/no/such/file"}},
+		},
+	}
+	_, err := ps.Resolve()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `parameter "missing"`)
+}
@@ -0,0 +1,131 @@
+// Package redact masks secret values in output before it is written anywhere.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Mask replaces redacted secrets.
+const Mask = "*****"
+
+// Writer replaces every occurrence of a set of secrets in the data written to it with Mask before passing it on.
+//
+// A secret that is split across several calls to Write is still masked: the end of the written data that could be
+// the beginning of a secret is held back until enough data has been written to tell. Close must be called to write
+// out the data that is held back.
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	secrets [][]byte
+	pending []byte
+}
+
+// NewWriter returns a Writer that masks secrets in what is written to w.
+//
+// Besides their literal values, secrets are masked in their JSON-escaped form, which is how values containing
+// quotes or newlines show up in structured output. Empty secrets are ignored.
+func NewWriter(w io.Writer, secrets []string) *Writer {
+	return &Writer{w: w, secrets: variants(secrets)}
+}
+
+// variants returns the forms of secrets that are masked: each non-empty secret and, if it differs, its JSON-escaped
+// form. Longer forms are returned first, so that a secret is masked entirely even if another secret is part of it.
+func variants(secrets []string) [][]byte {
+	seen := map[string]bool{}
+	forms := [][]byte{}
+	add := func(s string) {
+		if s == "" || seen[s] {
+			return
+		}
+		seen[s] = true
+		forms = append(forms, []byte(s))
+	}
+	for _, s := range secrets {
+		add(s)
+		if escaped, err := json.Marshal(s); err == nil {
+			add(string(escaped[1 : len(escaped)-1]))
+		}
+	}
+	sort.SliceStable(forms, func(i, j int) bool { return len(forms[i]) > len(forms[j]) })
+	return forms
+}
+
+// String masks secrets in s.
+func String(s string, secrets []string) string {
+	out, _ := mask([]byte(s), variants(secrets), true)
+	return string(out)
+}
+
+// Write masks the secrets in p and writes the result to the underlying writer, except for a trailing part of p that
+// might be the start of a secret.
+//
+// It always reports len(p) bytes written unless the underlying writer fails.
+func (r *Writer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending = append(r.pending, p...)
+	out, rest := mask(r.pending, r.secrets, false)
+	r.pending = append(r.pending[:0], rest...)
+	if len(out) > 0 {
+		if _, err := r.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close writes out any data that was held back. It does not close the underlying writer.
+func (r *Writer) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out, _ := mask(r.pending, r.secrets, true)
+	r.pending = r.pending[:0]
+	if len(out) == 0 {
+		return nil
+	}
+	_, err := r.w.Write(out)
+	return err
+}
+
+// mask replaces the secrets in data. Unless final is set, the longest suffix of data that is a proper prefix of a
+// secret is not masked but returned as rest.
+func mask(data []byte, secrets [][]byte, final bool) (out, rest []byte) {
+	var buf bytes.Buffer
+	i := 0
+	for i < len(data) {
+		matched := false
+		for _, s := range secrets {
+			if bytes.HasPrefix(data[i:], s) {
+				buf.WriteString(Mask)
+				i += len(s)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if !final && isPartialSecret(data[i:], secrets) {
+			return buf.Bytes(), data[i:]
+		}
+		buf.WriteByte(data[i])
+		i++
+	}
+	return buf.Bytes(), nil
+}
+
+// isPartialSecret reports whether data is a proper prefix of one of the secrets.
+func isPartialSecret(data []byte, secrets [][]byte) bool {
+	for _, s := range secrets {
+		if len(data) < len(s) && bytes.HasPrefix(s, data) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package redact
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	is := assert.New(t)
+
+	var out bytes.Buffer
+	w := NewWriter(&out, []string{"hunter2", "", "line1\nline2"})
+	for _, chunk := range []string{"password: hun", "ter2\n", "json: \"line1\\nli", "ne2\"\n", "not a secret: hunt"} {
+		n, err := w.Write([]byte(chunk))
+		is.NoError(err)
+		is.Equal(len(chunk), n)
+	}
+	is.Equal("password: *****\njson: \"*****\"\nnot a secret: ", out.String(), "a possible start of a secret is held back")
+
+	is.NoError(w.Close())
+	is.Equal("password: *****\njson: \"*****\"\nnot a secret: hunt", out.String())
+}
+
+func TestWriterOverlappingSecrets(t *testing.T) {
+	is := assert.New(t)
+
+	var out bytes.Buffer
+	w := NewWriter(&out, []string{"abc", "abcdef"})
+	_, err := w.Write([]byte("abcdef abc ab"))
+	is.NoError(err)
+	is.NoError(w.Close())
+	is.Equal("***** ***** ab", out.String(), "the longest secret wins")
+}
+
+func TestString(t *testing.T) {
+	is := assert.New(t)
+	is.Equal("token=*****", String("token=s3cr3t", []string{"s3cr3t"}))
+	is.Equal("nothing to hide", String("nothing to hide", nil))
+}
@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
-	"io/ioutil"
 	"os"
 	"sort"
 
 	"github.com/Masterminds/semver"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/cnabio/duffle/pkg/crypto/digest"
+	"github.com/cnabio/duffle/pkg/filelock"
+	"github.com/cnabio/duffle/pkg/osutil"
 )
 
 var (
@@ -20,6 +23,8 @@ var (
 	ErrNoBundleVersion = errors.New("no bundle with the given version found")
 	// ErrNoBundleName indicates that a bundle with the given name is not found.
 	ErrNoBundleName = errors.New("no bundle name found")
+	// ErrNoBundleDigest indicates that a bundle with the given name has no version stored under the given digest.
+	ErrNoBundleDigest = errors.New("no bundle with the given digest found")
 )
 
 type BundleVersion struct {
@@ -135,6 +140,22 @@ func (i Index) Get(name, version string) (string, error) {
 	return "", ErrNoBundleVersion
 }
 
+// GetDigest returns the stored digest of the bundle with the given name whose content is identified by query.
+//
+// The query may be the full digest or the legacy, truncated form of it.
+func (i Index) GetDigest(name, query string) (string, error) {
+	versions, ok := i[name]
+	if !ok {
+		return "", ErrNoBundleName
+	}
+	for _, d := range versions {
+		if digest.Matches(d, query) {
+			return d, nil
+		}
+	}
+	return "", ErrNoBundleDigest
+}
+
 // GetVersions gets all of the versions for the given name.
 //
 // If the name is not found, this will return false.
@@ -158,10 +179,12 @@ func (i Index) GetVersions(name string) ([]BundleVersion, bool) {
 	return bv, ok
 }
 
-func (i Index) versionsWithDigest(digest string) (map[string]string, bool) {
+// versionsWithDigest looks up the first version stored under the given digest, which may be a full digest or
+// a legacy, truncated one.
+func (i Index) versionsWithDigest(query string) (map[string]string, bool) {
 	for _, versions := range i {
 		for v, d := range versions {
-			if d == digest {
+			if digest.Matches(d, query) {
 				return map[string]string{v: d}, true
 			}
 		}
@@ -170,6 +193,30 @@ func (i Index) versionsWithDigest(digest string) (map[string]string, bool) {
 	return nil, false
 }
 
+// Rekey points every entry stored under the digest old at the digest new instead.
+func (i Index) Rekey(old, new string) {
+	for _, versions := range i {
+		for v, d := range versions {
+			if d == old {
+				versions[v] = new
+			}
+		}
+	}
+}
+
+// References returns the number of entries in the index that point at the given digest.
+func (i Index) References(digest string) int {
+	count := 0
+	for _, versions := range i {
+		for _, d := range versions {
+			if d == digest {
+				count++
+			}
+		}
+	}
+	return count
+}
+
 // WriteFile writes an index file to the given destination path.
 //
 // The mode on the file is set to 'mode'.
@@ -178,7 +225,35 @@ func (i Index) WriteFile(dest string, mode os.FileMode) error {
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(dest, b, mode)
+	return osutil.AtomicWriteFile(dest, b, mode)
+}
+
+// LockIndex takes the advisory lock guarding the index file at path, waiting for other processes to release it.
+//
+// The lock must be held for the whole read-modify-write cycle of the index, and released once the index is written.
+func LockIndex(path string) (*filelock.Lock, error) {
+	return filelock.Acquire(path+".lock", filelock.DefaultTimeout)
+}
+
+// UpdateIndex loads the index file at path, applies fn to it and writes it back with the given mode, while holding
+// the index lock.
+//
+// If fn returns an error, the index file is left untouched.
+func UpdateIndex(path string, mode os.FileMode, fn func(Index) error) error {
+	lock, err := LockIndex(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	i, err := LoadIndex(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(i); err != nil {
+		return err
+	}
+	return i.WriteFile(path, mode)
 }
 
 // Merge merges the src index into i (dest).
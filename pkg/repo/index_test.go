@@ -3,8 +3,12 @@ package repo
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"sync"
 	"testing"
 
 	"github.com/Masterminds/semver"
@@ -124,3 +128,82 @@ func TestGet(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVersionsByLegacyDigest(t *testing.T) {
+	is := assert.New(t)
+	full := "sha256:7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"
+	i := Index{}
+	i.Add("foo", "1.0.0", full)
+	i.Add("bar", "2.0.0", "sha256:0000000000000000000000000000000000000000000000000000000000000000")
+
+	for _, query := range []string{full, "7509e5bda0c762d2bac7f90d758b5b2263fa01cc"} {
+		vers, ok := i.GetVersions(query)
+		is.True(ok, query)
+		is.Len(vers, 1)
+		is.Equal(full, vers[0].Digest)
+		is.Equal("1.0.0", vers[0].Version.String())
+	}
+
+	i.Rekey(full, "sha256:1111111111111111111111111111111111111111111111111111111111111111")
+	is.Equal(0, i.References(full))
+	is.Equal(1, i.References("sha256:1111111111111111111111111111111111111111111111111111111111111111"))
+}
+
+func TestUpdateIndexConcurrent(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "duffle-index")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "repositories.json")
+
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			err := UpdateIndex(path, 0644, func(i Index) error {
+				i.Add("foo", fmt.Sprintf("1.0.%d", n), fmt.Sprintf("sha256:%064d", n))
+				return nil
+			})
+			is.NoError(err)
+		}(n)
+	}
+	wg.Wait()
+
+	index, err := LoadIndex(path)
+	is.NoError(err)
+	vers, ok := index.GetVersions("foo")
+	is.True(ok)
+	is.Len(vers, 20)
+	lock, err := LockIndex(path)
+	is.NoError(err, "the index lock is released")
+	is.NoError(lock.Release())
+}
+
+func TestGetDigest(t *testing.T) {
+	is := assert.New(t)
+	full := "sha256:7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"
+	legacy := "18d7e9e4f6e3db0d6e6b7bc8a9b3f4ad2bc6d6b1"
+	index := Index{
+		"foo": {"1.0.0": full, "0.1.0": legacy},
+		"bar": {"1.0.0": "sha256:1234"},
+	}
+
+	d, err := index.GetDigest("foo", full)
+	is.NoError(err)
+	is.Equal(full, d)
+
+	d, err = index.GetDigest("foo", "7509e5bda0c762d2bac7f90d758b5b2263fa01cc")
+	is.NoError(err)
+	is.Equal(full, d)
+
+	d, err = index.GetDigest("foo", legacy)
+	is.NoError(err)
+	is.Equal(legacy, d)
+
+	_, err = index.GetDigest("bar", full)
+	is.Equal(ErrNoBundleDigest, err)
+
+	_, err = index.GetDigest("baz", full)
+	is.Equal(ErrNoBundleName, err)
+}
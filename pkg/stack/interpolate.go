@@ -0,0 +1,114 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// reference matches a reference to an output of an installation, as in ${db.outputs.connection_string}.
+var reference = regexp.MustCompile(`\$\{([a-zA-Z0-9][a-zA-Z0-9_-]*)\.outputs\.([a-zA-Z0-9_.-]+)\}`)
+
+// OutputRef is a reference to an output of an installation.
+type OutputRef struct {
+	Installation string
+	Output       string
+}
+
+func (r OutputRef) String() string {
+	return fmt.Sprintf("${%s.outputs.%s}", r.Installation, r.Output)
+}
+
+// OutputLookup returns the value of an output of an installation.
+type OutputLookup func(ref OutputRef) (interface{}, error)
+
+// ResolveParameters returns the parameters of the installation, with the references to outputs replaced by the
+// values returned by lookup.
+//
+// A string that consists of a single reference is replaced by the value of the output. References inside longer
+// strings are replaced by the value of the output as a string. Strings in lists and objects are resolved too.
+func (i Installation) ResolveParameters(lookup OutputLookup) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(i.Parameters))
+	for name, val := range i.Parameters {
+		v, err := resolve(val, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q of %q: %v", name, i.Name, err)
+		}
+		resolved[name] = v
+	}
+	return resolved, nil
+}
+
+func resolve(val interface{}, lookup OutputLookup) (interface{}, error) {
+	switch v := val.(type) {
+	case string:
+		return resolveString(v, lookup)
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := resolve(item, lookup)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = r
+		}
+		return res, nil
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			r, err := resolve(item, lookup)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = r
+		}
+		return res, nil
+	default:
+		return val, nil
+	}
+}
+
+func resolveString(s string, lookup OutputLookup) (interface{}, error) {
+	if m := reference.FindStringSubmatch(s); m != nil && m[0] == s {
+		return lookup(OutputRef{Installation: m[1], Output: m[2]})
+	}
+
+	var err error
+	res := reference.ReplaceAllStringFunc(s, func(match string) string {
+		if err != nil {
+			return ""
+		}
+		m := reference.FindStringSubmatch(match)
+		var val interface{}
+		if val, err = lookup(OutputRef{Installation: m[1], Output: m[2]}); err != nil {
+			return ""
+		}
+		if str, ok := val.(string); ok {
+			return str
+		}
+		var raw []byte
+		raw, err = json.Marshal(val)
+		return string(raw)
+	})
+	return res, err
+}
+
+// references returns the references to outputs in val.
+func references(val interface{}) []OutputRef {
+	var refs []OutputRef
+	switch v := val.(type) {
+	case string:
+		for _, m := range reference.FindAllStringSubmatch(v, -1) {
+			refs = append(refs, OutputRef{Installation: m[1], Output: m[2]})
+		}
+	case []interface{}:
+		for _, item := range v {
+			refs = append(refs, references(item)...)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			refs = append(refs, references(item)...)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,181 @@
+// Package stack reads stack files, which describe several installations that are managed together.
+//
+// The parameters of an installation can refer to the outputs of other installations in the stack, as in
+// ${db.outputs.connection_string}. Installations are run in an order where every installation comes after the
+// installations whose outputs it uses.
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// DefaultFile is the name of the stack file used when none is given.
+const DefaultFile = "stack.yaml"
+
+// validName matches the names of stacks and installations.
+var validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// Stack is a set of installations that are managed together.
+type Stack struct {
+	// Name is the name of the stack.
+	Name string `json:"name"`
+	// Installations are the installations of the stack.
+	Installations []Installation `json:"installations"`
+
+	// dir is the directory of the stack file, which relative paths are resolved against.
+	dir string
+}
+
+// Installation is an installation of a bundle in a stack.
+type Installation struct {
+	// Name is the name of the installation.
+	Name string `json:"name"`
+	// Bundle is the name of the bundle in the local bundle store, as in name:version.
+	Bundle string `json:"bundle,omitempty"`
+	// BundleFile is the path of a bundle file, relative to the stack file.
+	BundleFile string `json:"bundleFile,omitempty"`
+	// Credentials are the names of the credential sets to use, or paths of credential set files relative to the
+	// stack file.
+	Credentials []string `json:"credentials,omitempty"`
+	// Parameters are the parameter values of the installation, which may refer to the outputs of other
+	// installations.
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// Load reads and validates the stack file at path.
+func Load(path string) (*Stack, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stack file %s: %v", path, err)
+	}
+	if s.dir, err = filepath.Abs(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Parse parses and validates a stack.
+func Parse(data []byte) (*Stack, error) {
+	s := &Stack{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, s.Validate()
+}
+
+// Validate checks that the stack is well-formed, that its parameters refer to outputs of installations in the stack,
+// and that they do not refer to each other in a cycle.
+func (s *Stack) Validate() error {
+	if !validName.MatchString(s.Name) {
+		return fmt.Errorf("invalid stack name %q", s.Name)
+	}
+	if len(s.Installations) == 0 {
+		return errors.New("the stack has no installations")
+	}
+	names := map[string]bool{}
+	for _, inst := range s.Installations {
+		if !validName.MatchString(inst.Name) {
+			return fmt.Errorf("invalid installation name %q", inst.Name)
+		}
+		if names[inst.Name] {
+			return fmt.Errorf("installation %q is declared more than once", inst.Name)
+		}
+		names[inst.Name] = true
+		if (inst.Bundle == "") == (inst.BundleFile == "") {
+			return fmt.Errorf("installation %q must have either a bundle or a bundleFile", inst.Name)
+		}
+	}
+	for _, inst := range s.Installations {
+		for _, dep := range inst.DependsOn() {
+			if !names[dep] {
+				return fmt.Errorf("installation %q refers to the outputs of %q, which is not in the stack", inst.Name, dep)
+			}
+			if dep == inst.Name {
+				return fmt.Errorf("installation %q refers to its own outputs", inst.Name)
+			}
+		}
+	}
+	_, err := s.Order()
+	return err
+}
+
+// Path resolves a path in the stack file against the directory of the stack file.
+func (s *Stack) Path(path string) string {
+	if path == "" || filepath.IsAbs(path) || s.dir == "" {
+		return path
+	}
+	return filepath.Join(s.dir, path)
+}
+
+// Installation returns the installation with the given name.
+func (s *Stack) Installation(name string) (Installation, bool) {
+	for _, inst := range s.Installations {
+		if inst.Name == name {
+			return inst, true
+		}
+	}
+	return Installation{}, false
+}
+
+// DependsOn returns the names of the installations whose outputs the parameters of the installation refer to, sorted.
+func (i Installation) DependsOn() []string {
+	deps := map[string]bool{}
+	for _, ref := range references(i.Parameters) {
+		deps[ref.Installation] = true
+	}
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Order returns the installations in the order they are installed in: each installation comes after the
+// installations it depends on. Installations that do not depend on each other keep the order of the stack file.
+func (s *Stack) Order() ([]Installation, error) {
+	done := map[string]bool{}
+	order := make([]Installation, 0, len(s.Installations))
+	for len(order) < len(s.Installations) {
+		progress := false
+		for _, inst := range s.Installations {
+			if done[inst.Name] || !allDone(inst.DependsOn(), done) {
+				continue
+			}
+			done[inst.Name] = true
+			order = append(order, inst)
+			progress = true
+		}
+		if !progress {
+			var cycle []string
+			for _, inst := range s.Installations {
+				if !done[inst.Name] {
+					cycle = append(cycle, inst.Name)
+				}
+			}
+			return nil, fmt.Errorf("the outputs and parameters of %s depend on each other in a cycle", strings.Join(cycle, ", "))
+		}
+	}
+	return order, nil
+}
+
+func allDone(names []string, done map[string]bool) bool {
+	for _, name := range names {
+		if !done[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package stack
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testStack = `name: shop
+installations:
+- name: app
+  bundle: shop:1.0.0
+  credentials: [azure]
+  parameters:
+    database: ${db.outputs.connection_string}
+    url: https://${network.outputs.host}:${network.outputs.port}/
+- name: network
+  bundleFile: network/bundle.json
+- name: db
+  bundle: postgres:11
+  parameters:
+    subnet: ${network.outputs.subnet}
+    replicas: 2
+`
+
+func TestParseAndOrder(t *testing.T) {
+	is := assert.New(t)
+
+	s, err := Parse([]byte(testStack))
+	is.NoError(err)
+	is.Equal("shop", s.Name)
+	is.Len(s.Installations, 3)
+
+	app, ok := s.Installation("app")
+	is.True(ok)
+	is.Equal([]string{"db", "network"}, app.DependsOn())
+
+	order, err := s.Order()
+	is.NoError(err)
+	var names []string
+	for _, inst := range order {
+		names = append(names, inst.Name)
+	}
+	is.Equal([]string{"network", "db", "app"}, names)
+}
+
+func TestValidate(t *testing.T) {
+	is := assert.New(t)
+
+	for data, expected := range map[string]string{
+		"name: shop\n": "the stack has no installations",
+		"name: shop\ninstallations:\n- name: a\n  bundle: a:1\n- name: a\n  bundle: b:1\n":                                                                             `installation "a" is declared more than once`,
+		"name: shop\ninstallations:\n- name: a\n":                                                                                                                      `installation "a" must have either a bundle or a bundleFile`,
+		"name: shop\ninstallations:\n- name: a\n  bundle: a:1\n  parameters:\n    x: ${b.outputs.y}\n":                                                                 `installation "a" refers to the outputs of "b", which is not in the stack`,
+		"name: shop\ninstallations:\n- name: a\n  bundle: a:1\n  parameters:\n    x: ${a.outputs.y}\n":                                                                 `installation "a" refers to its own outputs`,
+		"name: sh op\ninstallations:\n- name: a\n  bundle: a:1\n":                                                                                                      `invalid stack name "sh op"`,
+		"name: shop\ninstallations:\n- name: a\n  bundle: a:1\n  parameters:\n    x: ${b.outputs.y}\n- name: b\n  bundle: b:1\n  parameters:\n    x: ${a.outputs.y}\n": "the outputs and parameters of a, b depend on each other in a cycle",
+	} {
+		_, err := Parse([]byte(data))
+		is.EqualError(err, expected, data)
+	}
+}
+
+func TestResolveParameters(t *testing.T) {
+	is := assert.New(t)
+
+	s, err := Parse([]byte(testStack))
+	is.NoError(err)
+	outputs := map[string]interface{}{
+		"${db.outputs.connection_string}": "postgres://db:5432",
+		"${network.outputs.host}":         "shop.example.com",
+		"${network.outputs.port}":         float64(443),
+	}
+	lookup := func(ref OutputRef) (interface{}, error) {
+		if v, ok := outputs[ref.String()]; ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s has no output %q", ref.Installation, ref.Output)
+	}
+
+	app, _ := s.Installation("app")
+	params, err := app.ResolveParameters(lookup)
+	is.NoError(err)
+	is.Equal(map[string]interface{}{
+		"database": "postgres://db:5432",
+		"url":      "https://shop.example.com:443/",
+	}, params)
+
+	db, _ := s.Installation("db")
+	_, err = db.ResolveParameters(lookup)
+	is.EqualError(err, `parameter "subnet" of "db": network has no output "subnet"`)
+
+	inst := Installation{Name: "x", Parameters: map[string]interface{}{
+		"port": "${network.outputs.port}",
+		"list": []interface{}{"${network.outputs.host}", true},
+	}}
+	params, err = inst.ResolveParameters(lookup)
+	is.NoError(err)
+	is.Equal(float64(443), params["port"], "a single reference keeps the type of the output")
+	is.Equal([]interface{}{"shop.example.com", true}, params["list"])
+}